@@ -1,13 +1,69 @@
 package cache
 
-import "github.com/aidapedia/gdk/cache/engine"
+import (
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+	"golang.org/x/sync/singleflight"
+)
 
 type Cache struct {
 	engine.Interface
+	opt Options
+	sl  singleflight.Group
+}
+
+type Options struct {
+	// TTLJitter is the fraction of TTL randomly added to or subtracted from the TTL of GetOrLoad,
+	// so the keys stored at the same time do not expire at the same time.
+	// Default is 0.1. Set to negative to disable it. The value of 1 or more is lowered to 0.9,
+	// so the jittered TTL stays positive.
+	TTLJitter float64
+	// EarlyExpirationBeta scales the probability of GetOrLoad reloading the key before it expires.
+	// The key that takes longer to load is reloaded earlier. Greater than 1 favors the earlier reload.
+	// Default is 1. Set to negative to disable it.
+	EarlyExpirationBeta float64
+	// TagPrefix is prepended to the tag to get the key of the sorted set tracking the tagged keys.
+	// Default is "tag:".
+	TagPrefix string
+	// NamespacePrefix is prepended to the namespace to get the key of its generation counter.
+	// Default is "ns:".
+	NamespacePrefix string
+	// ResubscribeInterval is the wait time before subscribing again when the subscription of Subscribe fails.
+	// Default is 1 second.
+	ResubscribeInterval time.Duration
+}
+
+func (o *Options) setDefault() {
+	if o.TTLJitter == 0 {
+		o.TTLJitter = 0.1
+	}
+	if o.TTLJitter >= 1 {
+		o.TTLJitter = 0.9
+	}
+	if o.EarlyExpirationBeta == 0 {
+		o.EarlyExpirationBeta = 1
+	}
+	if o.TagPrefix == "" {
+		o.TagPrefix = "tag:"
+	}
+	if o.NamespacePrefix == "" {
+		o.NamespacePrefix = "ns:"
+	}
+	if o.ResubscribeInterval <= 0 {
+		o.ResubscribeInterval = time.Second
+	}
 }
 
 func NewCache(cli engine.Interface) *Cache {
+	return NewCacheWithOptions(cli, Options{})
+}
+
+// NewCacheWithOptions creates a new Cache with options of GetOrLoad, tags and namespaces.
+func NewCacheWithOptions(cli engine.Interface, opt Options) *Cache {
+	opt.setDefault()
 	return &Cache{
 		Interface: cli,
+		opt:       opt,
 	}
 }
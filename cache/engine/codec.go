@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"encoding"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aidapedia/gdk/codec"
+	"github.com/klauspost/compress/s2"
+)
+
+// frameMarker starts the framed value, followed by the format of the value. 0xc1 is never used by MessagePack,
+// JSON or gob as the first byte, so only the raw string or []byte that starts with it is framed.
+const frameMarker = "\xc1"
+
+const (
+	// compressedPrefix marks the value compressed with s2.
+	compressedPrefix = frameMarker + "s2"
+	// rawPrefix marks the raw value that starts with frameMarker, so it is not mistaken for the compressed one.
+	rawPrefix = frameMarker + "r"
+)
+
+// serializer converts the value to the string stored on the cache and back.
+//
+// The string, []byte, number, bool, time.Time, time.Duration and encoding.BinaryMarshaler value is
+// stored as is, the same as go-redis, so the counter and the plain string stay readable.
+// The value that starts with frameMarker is framed, so it is read back the same.
+// Any other value, e.g. struct, pointer, slice and map, is encoded by the codec.
+type serializer struct {
+	codec             codec.Codec
+	compressThreshold int
+}
+
+func newSerializer(c codec.Codec, compressThreshold int) serializer {
+	if c == nil {
+		c = codec.NewJSON()
+	}
+	return serializer{
+		codec:             c,
+		compressThreshold: compressThreshold,
+	}
+}
+
+func (s serializer) encode(val interface{}) (string, error) {
+	str, ok, err := formatArg(val)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		data, err := s.codec.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		str = string(data)
+	}
+	if s.compressThreshold > 0 && len(str) >= s.compressThreshold {
+		return compressedPrefix + string(s2.Encode(nil, []byte(str))), nil
+	}
+	if strings.HasPrefix(str, frameMarker) {
+		return rawPrefix + str, nil
+	}
+	return str, nil
+}
+
+func (s serializer) encodeAll(vals []interface{}) ([]string, error) {
+	encoded := make([]string, 0, len(vals))
+	for _, val := range vals {
+		str, err := s.encode(val)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, str)
+	}
+	return encoded, nil
+}
+
+// decode decompresses the value and removes the frame of the raw value. The unframed value is returned as is.
+func (s serializer) decode(str string) (string, error) {
+	if strings.HasPrefix(str, rawPrefix) {
+		return str[len(rawPrefix):], nil
+	}
+	if !strings.HasPrefix(str, compressedPrefix) {
+		return str, nil
+	}
+	data, err := s2.Decode(nil, []byte(str[len(compressedPrefix):]))
+	if err != nil {
+		return "", fmt.Errorf("decompress value: %w", err)
+	}
+	return string(data), nil
+}
+
+func (s serializer) decodeAll(strs []string) ([]string, error) {
+	decoded := make([]string, 0, len(strs))
+	for _, str := range strs {
+		val, err := s.decode(str)
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, val)
+	}
+	return decoded, nil
+}
+
+// scan parses the decoded value into dest. The destination of the type stored as is is parsed
+// the same way go-redis scans it, and the other destination is decoded by the codec.
+func (s serializer) scan(val string, dest interface{}) error {
+	var err error
+	switch d := dest.(type) {
+	case *string:
+		*d = val
+	case *[]byte:
+		*d = []byte(val)
+	case *int:
+		*d, err = strconv.Atoi(val)
+	case *int8:
+		var n int64
+		n, err = strconv.ParseInt(val, 10, 8)
+		*d = int8(n)
+	case *int16:
+		var n int64
+		n, err = strconv.ParseInt(val, 10, 16)
+		*d = int16(n)
+	case *int32:
+		var n int64
+		n, err = strconv.ParseInt(val, 10, 32)
+		*d = int32(n)
+	case *int64:
+		*d, err = strconv.ParseInt(val, 10, 64)
+	case *uint:
+		var n uint64
+		n, err = strconv.ParseUint(val, 10, 0)
+		*d = uint(n)
+	case *uint8:
+		var n uint64
+		n, err = strconv.ParseUint(val, 10, 8)
+		*d = uint8(n)
+	case *uint16:
+		var n uint64
+		n, err = strconv.ParseUint(val, 10, 16)
+		*d = uint16(n)
+	case *uint32:
+		var n uint64
+		n, err = strconv.ParseUint(val, 10, 32)
+		*d = uint32(n)
+	case *uint64:
+		*d, err = strconv.ParseUint(val, 10, 64)
+	case *float32:
+		var n float64
+		n, err = strconv.ParseFloat(val, 32)
+		*d = float32(n)
+	case *float64:
+		*d, err = strconv.ParseFloat(val, 64)
+	case *bool:
+		*d = len(val) == 1 && val[0] == '1'
+	case *time.Time:
+		*d, err = time.Parse(time.RFC3339Nano, val)
+	case *time.Duration:
+		var n int64
+		n, err = strconv.ParseInt(val, 10, 64)
+		*d = time.Duration(n)
+	case encoding.BinaryUnmarshaler:
+		err = d.UnmarshalBinary([]byte(val))
+	default:
+		err = s.codec.Unmarshal([]byte(val), dest)
+	}
+	return err
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/codec"
+)
+
+func TestSerializer_Codec(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		codec codec.Codec
+	}{
+		{name: "default"},
+		{name: "msgpack", codec: codec.NewMsgpack()},
+		{name: "gob", codec: codec.NewGob()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := newTestMemoryClient(t, MemoryClientOpt{Codec: tt.codec})
+
+			_ = cli.SET(ctx, "pointer", &memoryTestValue{Name: "gdk"}, 0)
+			var value memoryTestValue
+			if err := cli.GET(ctx, "pointer").Scan(&value); err != nil || value.Name != "gdk" {
+				t.Errorf("Scan() of pointer = %+v, %v, want gdk", value, err)
+			}
+
+			_ = cli.SET(ctx, "slice", []string{"a", "b"}, 0)
+			var slice []string
+			if err := cli.GET(ctx, "slice").Scan(&slice); err != nil || !reflect.DeepEqual(slice, []string{"a", "b"}) {
+				t.Errorf("Scan() of slice = %v, %v, want [a b]", slice, err)
+			}
+
+			_ = cli.HSET(ctx, "hash", map[string]interface{}{"map": map[string]int{"a": 1}})
+			var m map[string]int
+			if err := cli.HGET(ctx, "hash", "map").Scan(&m); err != nil || m["a"] != 1 {
+				t.Errorf("Scan() of map = %v, %v, want a=1", m, err)
+			}
+
+			// The plain value is stored as is regardless of the codec.
+			_ = cli.SET(ctx, "counter", 10, 0)
+			var n int64
+			if err := cli.GET(ctx, "counter").Scan(&n); err != nil || n != 10 {
+				t.Errorf("Scan() of counter = %d, %v, want 10", n, err)
+			}
+			if v, _ := cli.INCR(ctx, "counter", 0); v != 11 {
+				t.Errorf("INCR() = %d, want 11", v)
+			}
+		})
+	}
+}
+
+func TestSerializer_Scan(t *testing.T) {
+	s := newSerializer(nil, 0)
+	now := time.Now().Truncate(time.Millisecond)
+
+	var (
+		str string
+		b   bool
+		f   float64
+		tm  time.Time
+	)
+	tests := []struct {
+		val  string
+		dest interface{}
+		want interface{}
+	}{
+		{val: "hello", dest: &str, want: "hello"},
+		{val: "1", dest: &b, want: true},
+		{val: "1.5", dest: &f, want: 1.5},
+	}
+	for _, tt := range tests {
+		if err := s.scan(tt.val, tt.dest); err != nil {
+			t.Fatalf("scan(%q) failed: %v", tt.val, err)
+		}
+		if got := reflect.ValueOf(tt.dest).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("scan(%q) = %v, want %v", tt.val, got, tt.want)
+		}
+	}
+	if err := s.scan(now.Format(time.RFC3339Nano), &tm); err != nil || !tm.Equal(now) {
+		t.Errorf("scan() of time = %v, %v, want %v", tm, err, now)
+	}
+}
+
+func TestSerializer_Compression(t *testing.T) {
+	ctx := context.Background()
+	cli := newTestMemoryClient(t, MemoryClientOpt{CompressThreshold: 64})
+
+	long := strings.Repeat("gdk", 100)
+	_ = cli.SET(ctx, "long", long, 0)
+	_ = cli.SET(ctx, "short", "gdk", 0)
+
+	stored := cli.shard("long").items["long"].str
+	if !strings.HasPrefix(stored, compressedPrefix) || len(stored) >= len(long) {
+		t.Errorf("stored value of %d bytes is not compressed", len(stored))
+	}
+	if got := cli.GET(ctx, "long").Value(); got != long {
+		t.Errorf("GET() of compressed value = %d bytes, want %d", len(got), len(long))
+	}
+	if got := cli.shard("short").items["short"].str; got != "gdk" {
+		t.Errorf("stored value below threshold = %q, want gdk", got)
+	}
+
+	_ = cli.HSET(ctx, "hash", map[string]interface{}{"long": long})
+	if got := cli.HGET(ctx, "hash", "long").Value(); got != long {
+		t.Errorf("HGET() of compressed value = %d bytes, want %d", len(got), len(long))
+	}
+	if fields, _ := cli.HGETALL(ctx, "hash"); fields["long"] != long {
+		t.Errorf("HGETALL() of compressed value = %d bytes, want %d", len(fields["long"]), len(long))
+	}
+}
+
+func TestSerializer_Frame(t *testing.T) {
+	ctx := context.Background()
+	cli := newTestMemoryClient(t, MemoryClientOpt{CompressThreshold: 64})
+
+	tests := []struct {
+		name string
+		val  string
+	}{
+		{name: "compressed prefix", val: compressedPrefix + "gdk"},
+		{name: "raw prefix", val: rawPrefix + "gdk"},
+		{name: "marker", val: frameMarker},
+		{name: "long compressed prefix", val: compressedPrefix + strings.Repeat("gdk", 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = cli.SET(ctx, "string", tt.val, 0)
+			if got := cli.GET(ctx, "string").Value(); got != tt.val {
+				t.Errorf("GET() of string = %q, want %q", got, tt.val)
+			}
+			_ = cli.SET(ctx, "bytes", []byte(tt.val), 0)
+			var got []byte
+			if err := cli.GET(ctx, "bytes").Scan(&got); err != nil || string(got) != tt.val {
+				t.Errorf("Scan() of []byte = %q, %v, want %q", got, err, tt.val)
+			}
+			_ = cli.HSET(ctx, "hash", map[string]interface{}{"field": tt.val})
+			if got := cli.HGET(ctx, "hash", "field").Value(); got != tt.val {
+				t.Errorf("HGET() = %q, want %q", got, tt.val)
+			}
+		})
+	}
+}
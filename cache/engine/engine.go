@@ -2,7 +2,22 @@ package engine
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNil is the error returned when the key does not exist.
+// It is equal to redis.Nil, so every engine returns the same error.
+var ErrNil = redis.Nil
+
+const (
+	// TTLNoExpiration is the TTL of the key that exists but has no expiration.
+	TTLNoExpiration time.Duration = -1
+	// TTLKeyNotExist is the TTL of the key that does not exist.
+	TTLKeyNotExist time.Duration = -2
 )
 
 type Field struct {
@@ -10,6 +25,12 @@ type Field struct {
 	Value interface{}
 }
 
+// Z is the member of the sorted set.
+type Z struct {
+	Score  float64
+	Member interface{}
+}
+
 type Interface interface {
 	GET(ctx context.Context, key string) StringResult
 	SET(ctx context.Context, key string, val interface{}, exp time.Duration) error
@@ -17,6 +38,68 @@ type Interface interface {
 	HGET(ctx context.Context, key string, field string) StringResult
 	HGETALL(ctx context.Context, key string) (map[string]string, error)
 	DEL(ctx context.Context, keys ...string) error
+
+	// MGET returns the value of each key. The result of the missing key has ErrNil error.
+	MGET(ctx context.Context, keys ...string) []StringResult
+	MSET(ctx context.Context, values map[string]interface{}) error
+	// SETNX sets the value only if the key does not exist. It returns true if the value is set.
+	SETNX(ctx context.Context, key string, val interface{}, exp time.Duration) (bool, error)
+	// EXISTS returns the number of the keys that exist.
+	EXISTS(ctx context.Context, keys ...string) (int64, error)
+
+	// INCR, INCRBY, DECR and DECRBY set the expiration when the key has no expiration.
+	// Set exp to 0 to keep the key without expiration.
+	INCR(ctx context.Context, key string, exp time.Duration) (int64, error)
+	INCRBY(ctx context.Context, key string, value int64, exp time.Duration) (int64, error)
+	DECR(ctx context.Context, key string, exp time.Duration) (int64, error)
+	DECRBY(ctx context.Context, key string, value int64, exp time.Duration) (int64, error)
+
+	// EXPIRE returns false if the key does not exist.
+	EXPIRE(ctx context.Context, key string, exp time.Duration) (bool, error)
+	// TTL returns TTLNoExpiration or TTLKeyNotExist when the key has no expiration or does not exist.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+
+	ZADD(ctx context.Context, key string, members ...Z) (int64, error)
+	ZREM(ctx context.Context, key string, members ...interface{}) (int64, error)
+	ZSCORE(ctx context.Context, key string, member interface{}) (float64, error)
+	ZCARD(ctx context.Context, key string) (int64, error)
+	// ZRANGE returns the members by index, ordered from the lowest score.
+	ZRANGE(ctx context.Context, key string, start, stop int64) SliceResult
+	// ZRANGEBYSCORE returns the members with the score between min and max inclusive.
+	ZRANGEBYSCORE(ctx context.Context, key string, min, max float64) SliceResult
+	ZREMRANGEBYSCORE(ctx context.Context, key string, min, max float64) (int64, error)
+
+	LPUSH(ctx context.Context, key string, values ...interface{}) (int64, error)
+	RPUSH(ctx context.Context, key string, values ...interface{}) (int64, error)
+	LPOP(ctx context.Context, key string) StringResult
+	RPOP(ctx context.Context, key string) StringResult
+	LRANGE(ctx context.Context, key string, start, stop int64) SliceResult
+	LLEN(ctx context.Context, key string) (int64, error)
+	LTRIM(ctx context.Context, key string, start, stop int64) error
+
+	// Pipeline returns a new pipeline that sends the queued commands in one round trip.
+	Pipeline() Pipeliner
+	// TxPipeline returns a new pipeline that runs the queued commands atomically.
+	TxPipeline() Pipeliner
+
+	// PUBLISH sends the message to the channel and returns the number of the subscribers receiving it.
+	PUBLISH(ctx context.Context, channel string, msg interface{}) (int64, error)
+	// SUBSCRIBE listens to the channels until the subscription is closed.
+	SUBSCRIBE(ctx context.Context, channels ...string) (Subscription, error)
+	// PSUBSCRIBE listens to the channels matching the glob-style patterns until the subscription is closed.
+	PSUBSCRIBE(ctx context.Context, patterns ...string) (Subscription, error)
+}
+
+// RateLimitResult is the result of SLIDINGWINDOW and GCRA of GoRedisClient and MemoryClient.
+// See ratelimit.Backend.
+type RateLimitResult struct {
+	Allowed bool
+	// Remaining is the number of the hits allowed right after this one.
+	Remaining int64
+	// RetryAfter is the time until the denied hits are allowed. It is 0 when the hits are allowed.
+	RetryAfter time.Duration
+	// ResetAfter is the time until the limit is fully available again.
+	ResetAfter time.Duration
 }
 
 // StringResult is the result of a cache operation.
@@ -40,3 +123,37 @@ func (r StringResult) Scan(dest interface{}) error {
 	}
 	return r.unmarshal(r.value, dest)
 }
+
+// SliceResult is the result of a cache operation that returns many values.
+type SliceResult struct {
+	values    []string
+	err       error
+	unmarshal func(val string, dest interface{}) error
+}
+
+func (r SliceResult) Values() []string {
+	return r.values
+}
+
+func (r SliceResult) Err() error {
+	return r.err
+}
+
+// Scan decodes every value into dest. dest must be a pointer to a slice.
+func (r SliceResult) Scan(dest interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be a pointer to a slice, got %T", dest)
+	}
+	slice := reflect.MakeSlice(rv.Elem().Type(), len(r.values), len(r.values))
+	for i, val := range r.values {
+		if err := r.unmarshal(val, slice.Index(i).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	rv.Elem().Set(slice)
+	return nil
+}
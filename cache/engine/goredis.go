@@ -2,95 +2,659 @@ package engine
 
 import (
 	"context"
-	"reflect"
+	"math"
+	"strconv"
 	"time"
 
-	"github.com/bytedance/sonic"
+	"github.com/aidapedia/gdk/codec"
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
+// incrByScript increments the key and set the expiration when the key has no expiration.
+var incrByScript = redis.NewScript(`
+local val = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return val
+`)
+
+// delIfEqScript deletes the key when it holds the value.
+var delIfEqScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// expireIfEqScript sets the expiration of the key when it holds the value.
+var expireIfEqScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// setTaggedScript sets KEYS[1] and adds it to the sorted set of every tag on the rest of KEYS, scored by its
+// expiration in milliseconds, and prunes the expired members of the tags.
+var setTaggedScript = redis.NewScript(`
+local exp = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local score = '+inf'
+if exp > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', exp)
+	score = now + exp
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+for i = 2, #KEYS do
+	redis.call('ZADD', KEYS[i], score, KEYS[1])
+	redis.call('ZREMRANGEBYSCORE', KEYS[i], '-inf', now)
+end
+return 1
+`)
+
+// delTaggedScript deletes the members of the tags on KEYS together with the tags.
+var delTaggedScript = redis.NewScript(`
+for i = 1, #KEYS do
+	local members = redis.call('ZRANGE', KEYS[i], 0, -1)
+	for j = 1, #members do
+		redis.call('DEL', members[j])
+	end
+	redis.call('DEL', KEYS[i])
+end
+return 1
+`)
+
+// slidingWindowScript keeps the hits of the last window on a sorted set scored by the time in milliseconds.
+// The time of Redis is used, so every instance shares the same clock.
+var slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count + cost > limit then
+	local retry = window
+	local expiring = count + cost - limit
+	if expiring <= count then
+		local hit = redis.call('ZRANGE', KEYS[1], expiring - 1, expiring - 1, 'WITHSCORES')
+		retry = tonumber(hit[2]) + window - now
+	end
+	local reset = 0
+	if count > 0 then
+		local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+		reset = tonumber(newest[2]) + window - now
+	end
+	return {0, limit - count, retry, reset}
+end
+for i = 1, cost do
+	redis.call('ZADD', KEYS[1], now, ARGV[4] .. ':' .. i)
+end
+if cost > 0 then
+	redis.call('PEXPIRE', KEYS[1], window)
+end
+return {1, limit - count - cost, 0, window}
+`)
+
+// gcraScript stores the theoretical arrival time of the next hit in milliseconds.
+// The time of Redis is used, so every instance shares the same clock.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+local emission = period / rate
+local burst_offset = emission * burst
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + tonumber(t[2]) / 1000
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + emission * cost
+local diff = now - (new_tat - burst_offset)
+if diff < 0 then
+	local remaining = math.max(0, math.floor((now - (tat - burst_offset)) / emission))
+	return {0, remaining, math.ceil(-diff), math.ceil(tat - now)}
+end
+if cost > 0 then
+	redis.call('SET', KEYS[1], tostring(new_tat), 'PX', math.ceil(new_tat - now))
+end
+return {1, math.floor(diff / emission), 0, math.ceil(new_tat - now)}
+`)
+
+// GoRedisClient is the engine backed by go-redis. It works on the single node, failover,
+// cluster and ring client.
 type GoRedisClient struct {
-	*redis.Client
+	redis.UniversalClient
+	serializer serializer
+	// sharded is true when the keys are spread across many nodes, so the multi-key command
+	// is split into the single-key commands.
+	sharded bool
 }
 
 type GoRedisClientOpt struct {
 	Opt               *redis.Options
 	TracingInstrument bool
 	MetricsInstrument bool
+	// Codec encodes the value that can not be stored as is, e.g. struct, pointer, slice and map.
+	// Default is JSON.
+	Codec codec.Codec
+	// CompressThreshold is the minimum size in bytes of the encoded value compressed with s2.
+	// Default is 0, which means no compression.
+	CompressThreshold int
 }
 
-// NewGoRedisClient creates a new GoRedisClient.
+// NewGoRedisClient creates a new GoRedisClient on a single node.
 func NewGoRedisClient(opt GoRedisClientOpt) (Interface, error) {
-	client := redis.NewClient(opt.Opt)
+	return newGoRedisClient(redis.NewClient(opt.Opt), false, goRedisConfig{
+		tracing:           opt.TracingInstrument,
+		metrics:           opt.MetricsInstrument,
+		codec:             opt.Codec,
+		compressThreshold: opt.CompressThreshold,
+	})
+}
+
+type GoRedisClusterClientOpt struct {
+	Opt               *redis.ClusterOptions
+	TracingInstrument bool
+	MetricsInstrument bool
+	// Codec encodes the value that can not be stored as is, e.g. struct, pointer, slice and map.
+	// Default is JSON.
+	Codec codec.Codec
+	// CompressThreshold is the minimum size in bytes of the encoded value compressed with s2.
+	// Default is 0, which means no compression.
+	CompressThreshold int
+}
+
+// NewGoRedisClusterClient creates a new GoRedisClient on Redis Cluster.
+// MGET, MSET, DEL and EXISTS are sent per key, so MSET is not atomic across the slots.
+func NewGoRedisClusterClient(opt GoRedisClusterClientOpt) (Interface, error) {
+	return newGoRedisClient(redis.NewClusterClient(opt.Opt), true, goRedisConfig{
+		tracing:           opt.TracingInstrument,
+		metrics:           opt.MetricsInstrument,
+		codec:             opt.Codec,
+		compressThreshold: opt.CompressThreshold,
+	})
+}
+
+type GoRedisFailoverClientOpt struct {
+	Opt               *redis.FailoverOptions
+	TracingInstrument bool
+	MetricsInstrument bool
+	// Codec encodes the value that can not be stored as is, e.g. struct, pointer, slice and map.
+	// Default is JSON.
+	Codec codec.Codec
+	// CompressThreshold is the minimum size in bytes of the encoded value compressed with s2.
+	// Default is 0, which means no compression.
+	CompressThreshold int
+}
+
+// NewGoRedisFailoverClient creates a new GoRedisClient on the master monitored by Redis Sentinel.
+func NewGoRedisFailoverClient(opt GoRedisFailoverClientOpt) (Interface, error) {
+	return newGoRedisClient(redis.NewFailoverClient(opt.Opt), false, goRedisConfig{
+		tracing:           opt.TracingInstrument,
+		metrics:           opt.MetricsInstrument,
+		codec:             opt.Codec,
+		compressThreshold: opt.CompressThreshold,
+	})
+}
+
+type GoRedisRingClientOpt struct {
+	Opt               *redis.RingOptions
+	TracingInstrument bool
+	MetricsInstrument bool
+	// Codec encodes the value that can not be stored as is, e.g. struct, pointer, slice and map.
+	// Default is JSON.
+	Codec codec.Codec
+	// CompressThreshold is the minimum size in bytes of the encoded value compressed with s2.
+	// Default is 0, which means no compression.
+	CompressThreshold int
+}
+
+// NewGoRedisRingClient creates a new GoRedisClient on the shards of Redis Ring.
+// MGET, MSET, DEL and EXISTS are sent per key, so MSET is not atomic across the shards.
+func NewGoRedisRingClient(opt GoRedisRingClientOpt) (Interface, error) {
+	return newGoRedisClient(redis.NewRing(opt.Opt), true, goRedisConfig{
+		tracing:           opt.TracingInstrument,
+		metrics:           opt.MetricsInstrument,
+		codec:             opt.Codec,
+		compressThreshold: opt.CompressThreshold,
+	})
+}
+
+type goRedisConfig struct {
+	tracing           bool
+	metrics           bool
+	codec             codec.Codec
+	compressThreshold int
+}
+
+func newGoRedisClient(client redis.UniversalClient, sharded bool, cfg goRedisConfig) (Interface, error) {
 	err := client.Ping(context.Background()).Err()
 	if err != nil {
 		return nil, err
 	}
 
-	if opt.TracingInstrument {
+	if cfg.tracing {
 		if err := redisotel.InstrumentTracing(client); err != nil {
 			return nil, err
 		}
 	}
-	if opt.MetricsInstrument {
+	if cfg.metrics {
 		if err := redisotel.InstrumentMetrics(client); err != nil {
 			return nil, err
 		}
 	}
 
 	return &GoRedisClient{
-		Client: client,
+		UniversalClient: client,
+		serializer:      newSerializer(cfg.codec, cfg.compressThreshold),
+		sharded:         sharded,
 	}, nil
 }
 
 func (c *GoRedisClient) stringResult(cmd *redis.StringCmd) StringResult {
+	val, err := cmd.Result()
+	if err == nil {
+		val, err = c.serializer.decode(val)
+	}
 	return StringResult{
-		value:     cmd.Val(),
-		err:       cmd.Err(),
-		unmarshal: sonic.UnmarshalString,
+		value:     val,
+		err:       err,
+		unmarshal: c.serializer.scan,
+	}
+}
+
+func (c *GoRedisClient) sliceResult(cmd *redis.StringSliceCmd) SliceResult {
+	vals, err := cmd.Result()
+	if err == nil {
+		vals, err = c.serializer.decodeAll(vals)
+	}
+	return SliceResult{
+		values:    vals,
+		err:       err,
+		unmarshal: c.serializer.scan,
+	}
+}
+
+func (c *GoRedisClient) mapResult(cmd *redis.MapStringStringCmd) (map[string]string, error) {
+	fields, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+	for field, val := range fields {
+		if fields[field], err = c.serializer.decode(val); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+// encodeAll encodes every value as the command argument.
+func (c *GoRedisClient) encodeAll(vals []interface{}) ([]interface{}, error) {
+	strs, err := c.serializer.encodeAll(vals)
+	if err != nil {
+		return nil, err
 	}
+	encoded := make([]interface{}, 0, len(strs))
+	for _, str := range strs {
+		encoded = append(encoded, str)
+	}
+	return encoded, nil
 }
 
 func (c *GoRedisClient) GET(ctx context.Context, key string) StringResult {
-	return c.stringResult(c.Client.Get(ctx, key))
+	return c.stringResult(c.UniversalClient.Get(ctx, key))
 }
 
 func (c *GoRedisClient) SET(ctx context.Context, key string, val interface{}, exp time.Duration) error {
-	if reflect.ValueOf(val).Kind() == reflect.Struct {
-		jsonVal, err := sonic.MarshalString(val)
-		if err != nil {
-			return err
-		}
-		return c.Client.Set(ctx, key, jsonVal, exp).Err()
+	val, err := c.serializer.encode(val)
+	if err != nil {
+		return err
 	}
-	return c.Client.Set(ctx, key, val, exp).Err()
+	return c.UniversalClient.Set(ctx, key, val, exp).Err()
 }
 
 func (c *GoRedisClient) HSET(ctx context.Context, key string, fields map[string]interface{}) error {
 	values := []interface{}{}
 	for field, val := range fields {
-		if reflect.ValueOf(val).Kind() == reflect.Struct {
-			jsonVal, err := sonic.MarshalString(val)
-			if err != nil {
-				return err
-			}
-			values = append(values, field, jsonVal)
-		} else {
-			values = append(values, field, val)
+		val, err := c.serializer.encode(val)
+		if err != nil {
+			return err
 		}
+		values = append(values, field, val)
 	}
-	return c.Client.HSet(ctx, key, values...).Err()
+	return c.UniversalClient.HSet(ctx, key, values...).Err()
 }
 
 func (c *GoRedisClient) HGET(ctx context.Context, key string, field string) StringResult {
-	return c.stringResult(c.Client.HGet(ctx, key, field))
+	return c.stringResult(c.UniversalClient.HGet(ctx, key, field))
 }
 
 func (c *GoRedisClient) HGETALL(ctx context.Context, key string) (map[string]string, error) {
-	return c.Client.HGetAll(ctx, key).Result()
+	return c.mapResult(c.UniversalClient.HGetAll(ctx, key))
 }
 
 func (c *GoRedisClient) DEL(ctx context.Context, keys ...string) error {
-	return c.Client.Del(ctx, keys...).Err()
+	if c.sharded && len(keys) > 1 {
+		_, err := c.UniversalClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Del(ctx, key)
+			}
+			return nil
+		})
+		return err
+	}
+	return c.UniversalClient.Del(ctx, keys...).Err()
+}
+
+func (c *GoRedisClient) MGET(ctx context.Context, keys ...string) []StringResult {
+	if c.sharded && len(keys) > 1 {
+		return c.shardedMGET(ctx, keys)
+	}
+	results := make([]StringResult, len(keys))
+	vals, err := c.UniversalClient.MGet(ctx, keys...).Result()
+	for i := range keys {
+		results[i].unmarshal = c.serializer.scan
+		switch {
+		case err != nil:
+			results[i].err = err
+		case vals[i] == nil:
+			results[i].err = ErrNil
+		default:
+			str, _ := vals[i].(string)
+			results[i].value, results[i].err = c.serializer.decode(str)
+		}
+	}
+	return results
+}
+
+// shardedMGET gets every key on its own node in a pipeline, as the keys of MGET must be on the same slot.
+func (c *GoRedisClient) shardedMGET(ctx context.Context, keys []string) []StringResult {
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, _ = c.UniversalClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	results := make([]StringResult, len(keys))
+	for i, cmd := range cmds {
+		results[i] = c.stringResult(cmd)
+	}
+	return results
+}
+
+func (c *GoRedisClient) MSET(ctx context.Context, values map[string]interface{}) error {
+	pairs := make([]interface{}, 0, len(values)*2)
+	for key, val := range values {
+		val, err := c.serializer.encode(val)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, key, val)
+	}
+	if c.sharded && len(values) > 1 {
+		_, err := c.UniversalClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i := 0; i < len(pairs); i += 2 {
+				pipe.Set(ctx, pairs[i].(string), pairs[i+1], 0)
+			}
+			return nil
+		})
+		return err
+	}
+	return c.UniversalClient.MSet(ctx, pairs...).Err()
+}
+
+func (c *GoRedisClient) SETNX(ctx context.Context, key string, val interface{}, exp time.Duration) (bool, error) {
+	val, err := c.serializer.encode(val)
+	if err != nil {
+		return false, err
+	}
+	return c.UniversalClient.SetNX(ctx, key, val, exp).Result()
+}
+
+func (c *GoRedisClient) EXISTS(ctx context.Context, keys ...string) (int64, error) {
+	if c.sharded && len(keys) > 1 {
+		cmds := make([]*redis.IntCmd, len(keys))
+		_, err := c.UniversalClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = pipe.Exists(ctx, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		var n int64
+		for _, cmd := range cmds {
+			n += cmd.Val()
+		}
+		return n, nil
+	}
+	return c.UniversalClient.Exists(ctx, keys...).Result()
+}
+
+// DELIFEQ deletes the key only if it holds val. It returns true if the key is deleted.
+func (c *GoRedisClient) DELIFEQ(ctx context.Context, key string, val interface{}) (bool, error) {
+	val, err := c.serializer.encode(val)
+	if err != nil {
+		return false, err
+	}
+	n, err := delIfEqScript.Run(ctx, c.UniversalClient, []string{key}, val).Int64()
+	return n == 1, err
+}
+
+// EXPIREIFEQ sets the expiration only if the key holds val. It returns true if the expiration is set.
+func (c *GoRedisClient) EXPIREIFEQ(ctx context.Context, key string, val interface{}, exp time.Duration) (bool, error) {
+	val, err := c.serializer.encode(val)
+	if err != nil {
+		return false, err
+	}
+	n, err := expireIfEqScript.Run(ctx, c.UniversalClient, []string{key}, val, strconv.FormatInt(exp.Milliseconds(), 10)).Int64()
+	return n == 1, err
+}
+
+func (c *GoRedisClient) INCR(ctx context.Context, key string, exp time.Duration) (int64, error) {
+	return c.INCRBY(ctx, key, 1, exp)
+}
+
+func (c *GoRedisClient) INCRBY(ctx context.Context, key string, value int64, exp time.Duration) (int64, error) {
+	if exp <= 0 {
+		return c.UniversalClient.IncrBy(ctx, key, value).Result()
+	}
+	return incrByScript.Run(ctx, c.UniversalClient, []string{key}, value, strconv.FormatInt(exp.Milliseconds(), 10)).Int64()
+}
+
+func (c *GoRedisClient) DECR(ctx context.Context, key string, exp time.Duration) (int64, error) {
+	return c.INCRBY(ctx, key, -1, exp)
+}
+
+func (c *GoRedisClient) DECRBY(ctx context.Context, key string, value int64, exp time.Duration) (int64, error) {
+	return c.INCRBY(ctx, key, -value, exp)
+}
+
+func (c *GoRedisClient) EXPIRE(ctx context.Context, key string, exp time.Duration) (bool, error) {
+	return c.UniversalClient.Expire(ctx, key, exp).Result()
+}
+
+func (c *GoRedisClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return c.UniversalClient.PTTL(ctx, key).Result()
+}
+
+func (c *GoRedisClient) SLIDINGWINDOW(ctx context.Context, key string, limit int64, window time.Duration, n int64, id string) (RateLimitResult, error) {
+	return rateLimitResult(slidingWindowScript.Run(ctx, c.UniversalClient, []string{key}, limit, window.Milliseconds(), n, id).Int64Slice())
+}
+
+func (c *GoRedisClient) GCRA(ctx context.Context, key string, rate int64, period time.Duration, burst int64, n int64) (RateLimitResult, error) {
+	return rateLimitResult(gcraScript.Run(ctx, c.UniversalClient, []string{key}, rate, period.Milliseconds(), burst, n).Int64Slice())
+}
+
+// SETTAGGED sets the value and adds the key to the sorted set of every tag key, scored by the expiration in
+// milliseconds. It runs atomically on the single node. The keys of the cluster and ring client may be on
+// different nodes, so the commands are sent in one pipeline there.
+func (c *GoRedisClient) SETTAGGED(ctx context.Context, key string, val interface{}, exp time.Duration, tagKeys ...string) error {
+	str, err := c.serializer.encode(val)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixMilli()
+	ms := exp.Milliseconds()
+	if exp > 0 && ms == 0 {
+		ms = 1
+	}
+	if !c.sharded {
+		keys := append([]string{key}, tagKeys...)
+		return setTaggedScript.Run(ctx, c.UniversalClient, keys, str, ms, now).Err()
+	}
+
+	score := math.Inf(1)
+	if ms > 0 {
+		score = float64(now + ms)
+	}
+	_, err = c.UniversalClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, str, time.Duration(ms)*time.Millisecond)
+		for _, tagKey := range tagKeys {
+			pipe.ZAdd(ctx, tagKey, redis.Z{Score: score, Member: key})
+			pipe.ZRemRangeByScore(ctx, tagKey, "-inf", strconv.FormatInt(now, 10))
+		}
+		return nil
+	})
+	return err
+}
+
+// DELTAGGED deletes the keys added to the tag keys by SETTAGGED together with the tag keys.
+// It runs atomically on the single node. On the cluster and ring client, the members are read and deleted
+// per tag, and only the deleted members are removed from the tag, so the key added in between stays tracked.
+func (c *GoRedisClient) DELTAGGED(ctx context.Context, tagKeys ...string) error {
+	if len(tagKeys) == 0 {
+		return errWrongArgs("deltagged")
+	}
+	if !c.sharded {
+		return delTaggedScript.Run(ctx, c.UniversalClient, tagKeys).Err()
+	}
+
+	for _, tagKey := range tagKeys {
+		members, err := c.UniversalClient.ZRange(ctx, tagKey, 0, -1).Result()
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			continue
+		}
+		if err := c.DEL(ctx, members...); err != nil {
+			return err
+		}
+		args := make([]interface{}, len(members))
+		for i, member := range members {
+			args[i] = member
+		}
+		if err := c.UniversalClient.ZRem(ctx, tagKey, args...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rateLimitResult converts the reply of the rate limit script.
+func rateLimitResult(reply []int64, err error) (RateLimitResult, error) {
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return RateLimitResult{
+		Allowed:    reply[0] == 1,
+		Remaining:  reply[1],
+		RetryAfter: time.Duration(reply[2]) * time.Millisecond,
+		ResetAfter: time.Duration(reply[3]) * time.Millisecond,
+	}, nil
+}
+
+func (c *GoRedisClient) ZADD(ctx context.Context, key string, members ...Z) (int64, error) {
+	zs := make([]redis.Z, 0, len(members))
+	for _, m := range members {
+		member, err := c.serializer.encode(m.Member)
+		if err != nil {
+			return 0, err
+		}
+		zs = append(zs, redis.Z{Score: m.Score, Member: member})
+	}
+	return c.UniversalClient.ZAdd(ctx, key, zs...).Result()
+}
+
+func (c *GoRedisClient) ZREM(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	members, err := c.encodeAll(members)
+	if err != nil {
+		return 0, err
+	}
+	return c.UniversalClient.ZRem(ctx, key, members...).Result()
+}
+
+func (c *GoRedisClient) ZSCORE(ctx context.Context, key string, member interface{}) (float64, error) {
+	str, err := c.serializer.encode(member)
+	if err != nil {
+		return 0, err
+	}
+	return c.UniversalClient.ZScore(ctx, key, str).Result()
+}
+
+func (c *GoRedisClient) ZCARD(ctx context.Context, key string) (int64, error) {
+	return c.UniversalClient.ZCard(ctx, key).Result()
+}
+
+func (c *GoRedisClient) ZRANGE(ctx context.Context, key string, start, stop int64) SliceResult {
+	return c.sliceResult(c.UniversalClient.ZRange(ctx, key, start, stop))
+}
+
+func (c *GoRedisClient) ZRANGEBYSCORE(ctx context.Context, key string, min, max float64) SliceResult {
+	return c.sliceResult(c.UniversalClient.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: formatFloat(min),
+		Max: formatFloat(max),
+	}))
+}
+
+func (c *GoRedisClient) ZREMRANGEBYSCORE(ctx context.Context, key string, min, max float64) (int64, error) {
+	return c.UniversalClient.ZRemRangeByScore(ctx, key, formatFloat(min), formatFloat(max)).Result()
+}
+
+func (c *GoRedisClient) LPUSH(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	values, err := c.encodeAll(values)
+	if err != nil {
+		return 0, err
+	}
+	return c.UniversalClient.LPush(ctx, key, values...).Result()
+}
+
+func (c *GoRedisClient) RPUSH(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	values, err := c.encodeAll(values)
+	if err != nil {
+		return 0, err
+	}
+	return c.UniversalClient.RPush(ctx, key, values...).Result()
+}
+
+func (c *GoRedisClient) LPOP(ctx context.Context, key string) StringResult {
+	return c.stringResult(c.UniversalClient.LPop(ctx, key))
+}
+
+func (c *GoRedisClient) RPOP(ctx context.Context, key string) StringResult {
+	return c.stringResult(c.UniversalClient.RPop(ctx, key))
+}
+
+func (c *GoRedisClient) LRANGE(ctx context.Context, key string, start, stop int64) SliceResult {
+	return c.sliceResult(c.UniversalClient.LRange(ctx, key, start, stop))
+}
+
+func (c *GoRedisClient) LLEN(ctx context.Context, key string) (int64, error) {
+	return c.UniversalClient.LLen(ctx, key).Result()
+}
+
+func (c *GoRedisClient) LTRIM(ctx context.Context, key string, start, stop int64) error {
+	return c.UniversalClient.LTrim(ctx, key, start, stop).Err()
 }
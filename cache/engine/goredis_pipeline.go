@@ -0,0 +1,225 @@
+package engine
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (c *GoRedisClient) Pipeline() Pipeliner {
+	return &goRedisPipeline{c: c, pipe: c.UniversalClient.Pipeline()}
+}
+
+func (c *GoRedisClient) TxPipeline() Pipeliner {
+	return &goRedisPipeline{c: c, pipe: c.UniversalClient.TxPipeline()}
+}
+
+type goRedisPipeline struct {
+	c    *GoRedisClient
+	pipe redis.Pipeliner
+	// results sets the result of every queued command after Exec and returns its error.
+	results []func() error
+}
+
+func (p *goRedisPipeline) GET(ctx context.Context, key string) *StringCmd {
+	cmd := p.pipe.Get(ctx, key)
+	out := &StringCmd{}
+	p.results = append(p.results, func() error {
+		out.res = p.c.stringResult(cmd)
+		return out.res.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) SET(ctx context.Context, key string, val interface{}, exp time.Duration) *StatusCmd {
+	out := &StatusCmd{}
+	str, err := p.c.serializer.encode(val)
+	if err != nil {
+		return failStatus(p, out, err)
+	}
+	cmd := p.pipe.Set(ctx, key, str, exp)
+	p.results = append(p.results, func() error {
+		out.err = cmd.Err()
+		return out.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) SETNX(ctx context.Context, key string, val interface{}, exp time.Duration) *Cmd[bool] {
+	out := &Cmd[bool]{}
+	str, err := p.c.serializer.encode(val)
+	if err != nil {
+		return failCmd(p, out, err)
+	}
+	cmd := p.pipe.SetNX(ctx, key, str, exp)
+	p.results = append(p.results, func() error {
+		out.val, out.err = cmd.Result()
+		return out.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) HSET(ctx context.Context, key string, fields map[string]interface{}) *StatusCmd {
+	out := &StatusCmd{}
+	values := make([]interface{}, 0, len(fields)*2)
+	for field, val := range fields {
+		str, err := p.c.serializer.encode(val)
+		if err != nil {
+			return failStatus(p, out, err)
+		}
+		values = append(values, field, str)
+	}
+	cmd := p.pipe.HSet(ctx, key, values...)
+	p.results = append(p.results, func() error {
+		out.err = cmd.Err()
+		return out.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) HGET(ctx context.Context, key string, field string) *StringCmd {
+	cmd := p.pipe.HGet(ctx, key, field)
+	out := &StringCmd{}
+	p.results = append(p.results, func() error {
+		out.res = p.c.stringResult(cmd)
+		return out.res.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) HGETALL(ctx context.Context, key string) *Cmd[map[string]string] {
+	cmd := p.pipe.HGetAll(ctx, key)
+	out := &Cmd[map[string]string]{}
+	p.results = append(p.results, func() error {
+		out.val, out.err = p.c.mapResult(cmd)
+		return out.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) DEL(ctx context.Context, keys ...string) *StatusCmd {
+	out := &StatusCmd{}
+	// The keys of DEL must be on the same slot, so it is split on the sharded client.
+	cmds := make([]*redis.IntCmd, 0, len(keys))
+	if p.c.sharded && len(keys) > 1 {
+		for _, key := range keys {
+			cmds = append(cmds, p.pipe.Del(ctx, key))
+		}
+	} else {
+		cmds = append(cmds, p.pipe.Del(ctx, keys...))
+	}
+	p.results = append(p.results, func() error {
+		for _, cmd := range cmds {
+			if err := cmd.Err(); err != nil {
+				out.err = err
+				break
+			}
+		}
+		return out.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) EXISTS(ctx context.Context, keys ...string) *Cmd[int64] {
+	out := &Cmd[int64]{}
+	cmds := make([]*redis.IntCmd, 0, len(keys))
+	if p.c.sharded && len(keys) > 1 {
+		for _, key := range keys {
+			cmds = append(cmds, p.pipe.Exists(ctx, key))
+		}
+	} else {
+		cmds = append(cmds, p.pipe.Exists(ctx, keys...))
+	}
+	p.results = append(p.results, func() error {
+		for _, cmd := range cmds {
+			n, err := cmd.Result()
+			if err != nil {
+				out.val, out.err = 0, err
+				break
+			}
+			out.val += n
+		}
+		return out.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) INCRBY(ctx context.Context, key string, value int64, exp time.Duration) *Cmd[int64] {
+	out := &Cmd[int64]{}
+	if exp <= 0 {
+		cmd := p.pipe.IncrBy(ctx, key, value)
+		p.results = append(p.results, func() error {
+			out.val, out.err = cmd.Result()
+			return out.err
+		})
+		return out
+	}
+	// EVALSHA can not be retried with EVAL in a pipeline, so the script is always sent.
+	cmd := incrByScript.Eval(ctx, p.pipe, []string{key}, value, strconv.FormatInt(exp.Milliseconds(), 10))
+	p.results = append(p.results, func() error {
+		out.val, out.err = cmd.Int64()
+		return out.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) EXPIRE(ctx context.Context, key string, exp time.Duration) *Cmd[bool] {
+	cmd := p.pipe.Expire(ctx, key, exp)
+	out := &Cmd[bool]{}
+	p.results = append(p.results, func() error {
+		out.val, out.err = cmd.Result()
+		return out.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) TTL(ctx context.Context, key string) *Cmd[time.Duration] {
+	cmd := p.pipe.PTTL(ctx, key)
+	out := &Cmd[time.Duration]{}
+	p.results = append(p.results, func() error {
+		out.val, out.err = cmd.Result()
+		return out.err
+	})
+	return out
+}
+
+func (p *goRedisPipeline) Len() int {
+	return len(p.results)
+}
+
+func (p *goRedisPipeline) Exec(ctx context.Context) error {
+	// The error of Exec is the error of the first failed command, which is kept on its result too.
+	_, _ = p.pipe.Exec(ctx)
+
+	errs := make([]error, 0, len(p.results))
+	for _, result := range p.results {
+		errs = append(errs, result())
+	}
+	p.results = nil
+	return firstErr(errs...)
+}
+
+func (p *goRedisPipeline) Discard() {
+	p.pipe.Discard()
+	p.results = nil
+}
+
+// failStatus keeps the command that fails before it is queued, so Exec still reports its error.
+func failStatus(p *goRedisPipeline, out *StatusCmd, err error) *StatusCmd {
+	p.results = append(p.results, func() error {
+		out.err = err
+		return err
+	})
+	return out
+}
+
+// failCmd is failStatus of the command with a value.
+func failCmd[T any](p *goRedisPipeline, out *Cmd[T], err error) *Cmd[T] {
+	p.results = append(p.results, func() error {
+		out.err = err
+		return err
+	})
+	return out
+}
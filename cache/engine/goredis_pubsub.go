@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (c *GoRedisClient) PUBLISH(ctx context.Context, channel string, msg interface{}) (int64, error) {
+	str, err := c.serializer.encode(msg)
+	if err != nil {
+		return 0, err
+	}
+	return c.UniversalClient.Publish(ctx, channel, str).Result()
+}
+
+// SUBSCRIBE returns after Redis confirms the subscription. The connection is checked in the background,
+// and the broken one is reconnected and subscribed again, the messages published in between are lost.
+func (c *GoRedisClient) SUBSCRIBE(ctx context.Context, channels ...string) (Subscription, error) {
+	if len(channels) == 0 {
+		return nil, errWrongArgs("subscribe")
+	}
+	return c.subscribe(ctx, c.UniversalClient.Subscribe(ctx, channels...))
+}
+
+// PSUBSCRIBE is the same as SUBSCRIBE, but listens to the channels matching the patterns.
+func (c *GoRedisClient) PSUBSCRIBE(ctx context.Context, patterns ...string) (Subscription, error) {
+	if len(patterns) == 0 {
+		return nil, errWrongArgs("psubscribe")
+	}
+	return c.subscribe(ctx, c.UniversalClient.PSubscribe(ctx, patterns...))
+}
+
+func (c *GoRedisClient) subscribe(ctx context.Context, pubsub *redis.PubSub) (Subscription, error) {
+	// The first reply is the confirmation, so the message published after it is received.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	sub := &goRedisSubscription{
+		pubsub: pubsub,
+		ch:     make(chan *Message, subscriptionChannelSize),
+		done:   make(chan struct{}),
+	}
+	go sub.forward(pubsub.Channel(), c.serializer)
+	return sub, nil
+}
+
+type goRedisSubscription struct {
+	pubsub *redis.PubSub
+	ch     chan *Message
+	done   chan struct{}
+	once   sync.Once
+}
+
+// forward converts the messages of go-redis until the subscription is closed.
+func (s *goRedisSubscription) forward(in <-chan *redis.Message, ser serializer) {
+	defer close(s.ch)
+	for m := range in {
+		payload, err := ser.decode(m.Payload)
+		if err != nil {
+			payload = m.Payload
+		}
+		msg := &Message{
+			Channel:   m.Channel,
+			Pattern:   m.Pattern,
+			Payload:   payload,
+			err:       err,
+			unmarshal: ser.scan,
+		}
+		select {
+		case s.ch <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *goRedisSubscription) Channel() <-chan *Message {
+	return s.ch
+}
+
+func (s *goRedisSubscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+		err = s.pubsub.Close()
+	})
+	return err
+}
@@ -0,0 +1,1228 @@
+package engine
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aidapedia/gdk/codec"
+	"github.com/redis/go-redis/v9"
+)
+
+// Eviction is the policy used to remove a key when the memory engine is full.
+type Eviction int
+
+const (
+	// EvictionLRU removes the least recently used key.
+	EvictionLRU Eviction = iota
+	// EvictionLFU removes the least frequently used key among the least recently used ones.
+	EvictionLFU
+)
+
+// lfuSamples is the number of the least recently used keys compared by EvictionLFU.
+const lfuSamples = 5
+
+var (
+	errWrongType    = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errNotInteger   = errors.New("ERR value is not an integer or out of range")
+	errIncrOverflow = errors.New("ERR increment or decrement would overflow")
+)
+
+func errWrongArgs(cmd string) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%s' command", cmd)
+}
+
+type MemoryClientOpt struct {
+	// MaxKeys is the maximum number of keys kept by each shard.
+	// Default is 0, which means unlimited.
+	MaxKeys int
+	// Eviction is the policy used when a shard holds MaxKeys keys.
+	// Default is EvictionLRU.
+	Eviction Eviction
+	// Shards is the number of independently locked partitions of the key space.
+	// Default is 1.
+	Shards int
+	// CleanupInterval is the interval of removing the expired keys in the background.
+	// The expired key is never returned even before it is removed.
+	// Default is 1 minute. Set to negative to disable it.
+	CleanupInterval time.Duration
+	// Codec encodes the value that can not be stored as is, e.g. struct, pointer, slice and map.
+	// Default is JSON.
+	Codec codec.Codec
+	// CompressThreshold is the minimum size in bytes of the encoded value compressed with s2.
+	// Default is 0, which means no compression.
+	CompressThreshold int
+	// KeyspaceEvents publishes the set, del, expire, expired, evicted, hset, lpush and rpush events of the keys,
+	// the same as Redis with notify-keyspace-events. See KeyEventPattern.
+	// Unlike PUBLISH, the event is dropped for the subscriber whose buffer is full.
+	// Default is false.
+	KeyspaceEvents bool
+}
+
+func (o *MemoryClientOpt) setDefault() {
+	if o.Shards <= 0 {
+		o.Shards = 1
+	}
+	if o.CleanupInterval == 0 {
+		o.CleanupInterval = time.Minute
+	}
+}
+
+// MemoryClient is the in-process engine that behaves like GoRedisClient.
+type MemoryClient struct {
+	opt        MemoryClientOpt
+	serializer serializer
+	shards     []*memoryShard
+	pubsub     memoryPubSub
+	stop       chan struct{}
+	once       sync.Once
+}
+
+// NewMemoryClient creates a new MemoryClient. Call Close to stop the background cleanup.
+func NewMemoryClient(opt MemoryClientOpt) (*MemoryClient, error) {
+	opt.setDefault()
+	if opt.MaxKeys < 0 {
+		return nil, fmt.Errorf("invalid max keys %d", opt.MaxKeys)
+	}
+	if opt.Eviction != EvictionLRU && opt.Eviction != EvictionLFU {
+		return nil, fmt.Errorf("invalid eviction policy %d", opt.Eviction)
+	}
+
+	c := &MemoryClient{
+		opt:        opt,
+		serializer: newSerializer(opt.Codec, opt.CompressThreshold),
+		shards:     make([]*memoryShard, opt.Shards),
+		stop:       make(chan struct{}),
+	}
+	for i := range c.shards {
+		c.shards[i] = &memoryShard{
+			items:    make(map[string]*memoryItem),
+			lru:      list.New(),
+			maxKeys:  opt.MaxKeys,
+			eviction: opt.Eviction,
+			notify:   c.notify,
+		}
+	}
+	if opt.CleanupInterval > 0 {
+		go c.janitor(opt.CleanupInterval)
+	}
+	return c, nil
+}
+
+// Close stops the background cleanup. The stored keys are still accessible.
+func (c *MemoryClient) Close() error {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+	return nil
+}
+
+func (c *MemoryClient) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			now := nowMs()
+			for _, s := range c.shards {
+				s.mu.Lock()
+				for _, it := range s.items {
+					if it.expired(now) {
+						s.remove(it)
+						s.notify(EventExpired, it.key)
+					}
+				}
+				s.mu.Unlock()
+			}
+		}
+	}
+}
+
+func (c *MemoryClient) shard(key string) *memoryShard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return c.shards[h.Sum32()%uint32(len(c.shards))]
+}
+
+// lock locks the shard of every key in a fixed order and returns the function that unlocks them.
+func (c *MemoryClient) lock(keys ...string) func() {
+	if len(c.shards) == 1 {
+		c.shards[0].mu.Lock()
+		return c.shards[0].mu.Unlock
+	}
+	seen := make(map[*memoryShard]bool, len(keys))
+	for _, key := range keys {
+		seen[c.shard(key)] = true
+	}
+	locked := make([]*memoryShard, 0, len(seen))
+	for _, s := range c.shards {
+		if seen[s] {
+			s.mu.Lock()
+			locked = append(locked, s)
+		}
+	}
+	return func() {
+		for _, s := range locked {
+			s.mu.Unlock()
+		}
+	}
+}
+
+// lockAll locks every shard and returns the function that unlocks them.
+func (c *MemoryClient) lockAll() func() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+	}
+	return func() {
+		for _, s := range c.shards {
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (c *MemoryClient) stringResult(val string, err error) StringResult {
+	if err == nil {
+		val, err = c.serializer.decode(val)
+	}
+	return StringResult{
+		value:     val,
+		err:       err,
+		unmarshal: c.serializer.scan,
+	}
+}
+
+func (c *MemoryClient) sliceResult(vals []string, err error) SliceResult {
+	if vals == nil && err == nil {
+		vals = []string{}
+	}
+	if err == nil {
+		vals, err = c.serializer.decodeAll(vals)
+	}
+	return SliceResult{
+		values:    vals,
+		err:       err,
+		unmarshal: c.serializer.scan,
+	}
+}
+
+func (c *MemoryClient) GET(ctx context.Context, key string) StringResult {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.getLocked(key)
+}
+
+func (c *MemoryClient) getLocked(key string) StringResult {
+	it := c.shard(key).lookup(key)
+	if it == nil {
+		return c.stringResult("", ErrNil)
+	}
+	if it.kind != kindString {
+		return c.stringResult("", errWrongType)
+	}
+	return c.stringResult(it.str, nil)
+}
+
+func (c *MemoryClient) SET(ctx context.Context, key string, val interface{}, exp time.Duration) error {
+	str, err := c.serializer.encode(val)
+	if err != nil {
+		return err
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setString(key, str, exp)
+	return nil
+}
+
+func (c *MemoryClient) HSET(ctx context.Context, key string, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return errWrongArgs("hset")
+	}
+	values := make(map[string]string, len(fields))
+	for field, val := range fields {
+		str, err := c.serializer.encode(val)
+		if err != nil {
+			return err
+		}
+		values[field] = str
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.hsetLocked(key, values)
+}
+
+func (c *MemoryClient) hsetLocked(key string, values map[string]string) error {
+	s := c.shard(key)
+	it := s.lookup(key)
+	if it != nil && it.kind != kindHash {
+		return errWrongType
+	}
+	if it == nil {
+		it = s.create(key, kindHash)
+	}
+	for field, val := range values {
+		it.hash[field] = val
+	}
+	s.notify(EventHset, key)
+	return nil
+}
+
+func (c *MemoryClient) HGET(ctx context.Context, key string, field string) StringResult {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.hgetLocked(key, field)
+}
+
+func (c *MemoryClient) hgetLocked(key string, field string) StringResult {
+	it := c.shard(key).lookup(key)
+	if it == nil {
+		return c.stringResult("", ErrNil)
+	}
+	if it.kind != kindHash {
+		return c.stringResult("", errWrongType)
+	}
+	val, ok := it.hash[field]
+	if !ok {
+		return c.stringResult("", ErrNil)
+	}
+	return c.stringResult(val, nil)
+}
+
+func (c *MemoryClient) HGETALL(ctx context.Context, key string) (map[string]string, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.hgetallLocked(key)
+}
+
+func (c *MemoryClient) hgetallLocked(key string) (map[string]string, error) {
+	it := c.shard(key).lookup(key)
+	if it == nil {
+		return map[string]string{}, nil
+	}
+	if it.kind != kindHash {
+		return nil, errWrongType
+	}
+	fields := make(map[string]string, len(it.hash))
+	for field, val := range it.hash {
+		str, err := c.serializer.decode(val)
+		if err != nil {
+			return nil, err
+		}
+		fields[field] = str
+	}
+	return fields, nil
+}
+
+func (c *MemoryClient) DEL(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return errWrongArgs("del")
+	}
+	unlock := c.lock(keys...)
+	defer unlock()
+	return c.delLocked(keys)
+}
+
+func (c *MemoryClient) delLocked(keys []string) error {
+	for _, key := range keys {
+		s := c.shard(key)
+		if it := s.lookup(key); it != nil {
+			s.remove(it)
+			s.notify(EventDel, key)
+		}
+	}
+	return nil
+}
+
+func (c *MemoryClient) MGET(ctx context.Context, keys ...string) []StringResult {
+	results := make([]StringResult, len(keys))
+	if len(keys) == 0 {
+		return results
+	}
+	unlock := c.lock(keys...)
+	defer unlock()
+
+	for i, key := range keys {
+		it := c.shard(key).lookup(key)
+		if it == nil || it.kind != kindString {
+			results[i] = c.stringResult("", ErrNil)
+			continue
+		}
+		results[i] = c.stringResult(it.str, nil)
+	}
+	return results
+}
+
+func (c *MemoryClient) MSET(ctx context.Context, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return errWrongArgs("mset")
+	}
+	keys := make([]string, 0, len(values))
+	strs := make(map[string]string, len(values))
+	for key, val := range values {
+		str, err := c.serializer.encode(val)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		strs[key] = str
+	}
+
+	unlock := c.lock(keys...)
+	defer unlock()
+
+	for key, str := range strs {
+		c.shard(key).setString(key, str, 0)
+	}
+	return nil
+}
+
+func (c *MemoryClient) SETNX(ctx context.Context, key string, val interface{}, exp time.Duration) (bool, error) {
+	str, err := c.serializer.encode(val)
+	if err != nil {
+		return false, err
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.setnxLocked(key, str, exp)
+}
+
+func (c *MemoryClient) setnxLocked(key, str string, exp time.Duration) (bool, error) {
+	s := c.shard(key)
+	if s.lookup(key) != nil {
+		return false, nil
+	}
+	s.setString(key, str, exp)
+	return true, nil
+}
+
+func (c *MemoryClient) EXISTS(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, errWrongArgs("exists")
+	}
+	unlock := c.lock(keys...)
+	defer unlock()
+	return c.existsLocked(keys)
+}
+
+func (c *MemoryClient) existsLocked(keys []string) (int64, error) {
+	var n int64
+	for _, key := range keys {
+		if c.shard(key).lookup(key) != nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (c *MemoryClient) DELIFEQ(ctx context.Context, key string, val interface{}) (bool, error) {
+	str, err := c.serializer.encode(val)
+	if err != nil {
+		return false, err
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil || it.kind != kindString || it.str != str {
+		return false, nil
+	}
+	s.remove(it)
+	s.notify(EventDel, key)
+	return true, nil
+}
+
+func (c *MemoryClient) EXPIREIFEQ(ctx context.Context, key string, val interface{}, exp time.Duration) (bool, error) {
+	str, err := c.serializer.encode(val)
+	if err != nil {
+		return false, err
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil || it.kind != kindString || it.str != str {
+		return false, nil
+	}
+	// PEXPIRE with the non-positive expiration deletes the key.
+	ms := exp.Milliseconds()
+	if ms <= 0 {
+		s.remove(it)
+		s.notify(EventDel, key)
+		return true, nil
+	}
+	it.expireAt = nowMs() + ms
+	s.notify(EventExpire, key)
+	return true, nil
+}
+
+func (c *MemoryClient) INCR(ctx context.Context, key string, exp time.Duration) (int64, error) {
+	return c.INCRBY(ctx, key, 1, exp)
+}
+
+func (c *MemoryClient) INCRBY(ctx context.Context, key string, value int64, exp time.Duration) (int64, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.incrbyLocked(key, value, exp)
+}
+
+func (c *MemoryClient) incrbyLocked(key string, value int64, exp time.Duration) (int64, error) {
+	s := c.shard(key)
+	it := s.lookup(key)
+	if it != nil && it.kind != kindString {
+		return 0, errWrongType
+	}
+	var cur int64
+	if it != nil {
+		n, err := strconv.ParseInt(it.str, 10, 64)
+		if err != nil || strconv.FormatInt(n, 10) != it.str {
+			return 0, errNotInteger
+		}
+		cur = n
+	}
+	if (value > 0 && cur > math.MaxInt64-value) || (value < 0 && cur < math.MinInt64-value) {
+		return 0, errIncrOverflow
+	}
+	cur += value
+
+	if it == nil {
+		it = s.create(key, kindString)
+	}
+	it.str = strconv.FormatInt(cur, 10)
+	// Same as incrByScript, the expiration is only set when the key has no expiration.
+	if ms := exp.Milliseconds(); ms > 0 && it.expireAt == 0 {
+		it.expireAt = nowMs() + ms
+	}
+	return cur, nil
+}
+
+func (c *MemoryClient) DECR(ctx context.Context, key string, exp time.Duration) (int64, error) {
+	return c.INCRBY(ctx, key, -1, exp)
+}
+
+func (c *MemoryClient) DECRBY(ctx context.Context, key string, value int64, exp time.Duration) (int64, error) {
+	return c.INCRBY(ctx, key, -value, exp)
+}
+
+func (c *MemoryClient) EXPIRE(ctx context.Context, key string, exp time.Duration) (bool, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.expireLocked(key, exp)
+}
+
+func (c *MemoryClient) expireLocked(key string, exp time.Duration) (bool, error) {
+	s := c.shard(key)
+	it := s.lookup(key)
+	if it == nil {
+		return false, nil
+	}
+	// EXPIRE is sent in seconds, the non-positive one deletes the key.
+	sec := int64(exp / time.Second)
+	if exp > 0 && exp < time.Second {
+		sec = 1
+	}
+	if sec <= 0 {
+		s.remove(it)
+		s.notify(EventDel, key)
+		return true, nil
+	}
+	it.expireAt = nowMs() + sec*1000
+	s.notify(EventExpire, key)
+	return true, nil
+}
+
+func (c *MemoryClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.ttlLocked(key)
+}
+
+func (c *MemoryClient) ttlLocked(key string) (time.Duration, error) {
+	it := c.shard(key).lookup(key)
+	if it == nil {
+		return TTLKeyNotExist, nil
+	}
+	if it.expireAt == 0 {
+		return TTLNoExpiration, nil
+	}
+	return time.Duration(it.expireAt-nowMs()) * time.Millisecond, nil
+}
+
+// SLIDINGWINDOW works the same as slidingWindowScript.
+func (c *MemoryClient) SLIDINGWINDOW(ctx context.Context, key string, limit int64, window time.Duration, n int64, id string) (RateLimitResult, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it != nil && it.kind != kindZSet {
+		return RateLimitResult{}, errWrongType
+	}
+	now := nowMs()
+	windowMs := window.Milliseconds()
+	var hits []float64
+	if it != nil {
+		for member, score := range it.zset {
+			if score <= float64(now-windowMs) {
+				delete(it.zset, member)
+				continue
+			}
+			hits = append(hits, score)
+		}
+		s.removeIfEmpty(it)
+	}
+	sort.Float64s(hits)
+	count := int64(len(hits))
+
+	if count+n > limit {
+		res := RateLimitResult{
+			Remaining:  limit - count,
+			RetryAfter: time.Duration(windowMs) * time.Millisecond,
+		}
+		if expiring := count + n - limit; expiring <= count {
+			res.RetryAfter = time.Duration(int64(hits[expiring-1])+windowMs-now) * time.Millisecond
+		}
+		if count > 0 {
+			res.ResetAfter = time.Duration(int64(hits[count-1])+windowMs-now) * time.Millisecond
+		}
+		return res, nil
+	}
+	if n > 0 {
+		if it = s.lookup(key); it == nil {
+			it = s.create(key, kindZSet)
+		}
+		for i := int64(1); i <= n; i++ {
+			it.zset[id+":"+strconv.FormatInt(i, 10)] = float64(now)
+		}
+		it.expireAt = now + windowMs
+	}
+	return RateLimitResult{
+		Allowed:    true,
+		Remaining:  limit - count - n,
+		ResetAfter: window.Truncate(time.Millisecond),
+	}, nil
+}
+
+// GCRA works the same as gcraScript.
+func (c *MemoryClient) GCRA(ctx context.Context, key string, rate int64, period time.Duration, burst int64, n int64) (RateLimitResult, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := float64(time.Now().UnixMicro()) / 1000
+	tat := now
+	it := s.lookup(key)
+	if it != nil {
+		if it.kind != kindString {
+			return RateLimitResult{}, errWrongType
+		}
+		stored, err := strconv.ParseFloat(it.str, 64)
+		if err != nil {
+			return RateLimitResult{}, errors.New("ERR value is not a valid float")
+		}
+		tat = math.Max(stored, now)
+	}
+
+	emission := float64(period.Milliseconds()) / float64(rate)
+	burstOffset := emission * float64(burst)
+	newTat := tat + emission*float64(n)
+	diff := now - (newTat - burstOffset)
+	if diff < 0 {
+		return RateLimitResult{
+			Remaining:  int64(math.Max(0, math.Floor((now-(tat-burstOffset))/emission))),
+			RetryAfter: time.Duration(math.Ceil(-diff)) * time.Millisecond,
+			ResetAfter: time.Duration(math.Ceil(tat-now)) * time.Millisecond,
+		}, nil
+	}
+	if n > 0 {
+		s.setString(key, strconv.FormatFloat(newTat, 'f', -1, 64), time.Duration(math.Ceil(newTat-now))*time.Millisecond)
+	}
+	return RateLimitResult{
+		Allowed:    true,
+		Remaining:  int64(math.Floor(diff / emission)),
+		ResetAfter: time.Duration(math.Ceil(newTat-now)) * time.Millisecond,
+	}, nil
+}
+
+// SETTAGGED works the same as setTaggedScript, with the key and the tag keys locked together.
+func (c *MemoryClient) SETTAGGED(ctx context.Context, key string, val interface{}, exp time.Duration, tagKeys ...string) error {
+	str, err := c.serializer.encode(val)
+	if err != nil {
+		return err
+	}
+
+	unlock := c.lock(append([]string{key}, tagKeys...)...)
+	defer unlock()
+
+	for _, tagKey := range tagKeys {
+		if it := c.shard(tagKey).lookup(tagKey); it != nil && it.kind != kindZSet {
+			return errWrongType
+		}
+	}
+	s := c.shard(key)
+	s.setString(key, str, exp)
+	now := nowMs()
+	score := math.Inf(1)
+	if it := s.lookup(key); it != nil && it.expireAt > 0 {
+		score = float64(it.expireAt)
+	}
+	for _, tagKey := range tagKeys {
+		ts := c.shard(tagKey)
+		it := ts.lookup(tagKey)
+		if it == nil {
+			it = ts.create(tagKey, kindZSet)
+		}
+		it.zset[key] = score
+		for member, memberScore := range it.zset {
+			if memberScore <= float64(now) {
+				delete(it.zset, member)
+			}
+		}
+	}
+	return nil
+}
+
+// DELTAGGED works the same as delTaggedScript. Every shard is locked, as the members may be on any of them.
+func (c *MemoryClient) DELTAGGED(ctx context.Context, tagKeys ...string) error {
+	if len(tagKeys) == 0 {
+		return errWrongArgs("deltagged")
+	}
+
+	unlock := c.lockAll()
+	defer unlock()
+
+	for _, tagKey := range tagKeys {
+		ts := c.shard(tagKey)
+		it := ts.lookup(tagKey)
+		if it == nil {
+			continue
+		}
+		if it.kind != kindZSet {
+			return errWrongType
+		}
+		members := make([]string, 0, len(it.zset))
+		for member := range it.zset {
+			members = append(members, member)
+		}
+		if err := c.delLocked(members); err != nil {
+			return err
+		}
+		if it = ts.lookup(tagKey); it != nil {
+			ts.remove(it)
+			ts.notify(EventDel, tagKey)
+		}
+	}
+	return nil
+}
+
+func (c *MemoryClient) ZADD(ctx context.Context, key string, members ...Z) (int64, error) {
+	if len(members) == 0 {
+		return 0, errWrongArgs("zadd")
+	}
+	strs := make([]string, 0, len(members))
+	for _, m := range members {
+		if math.IsNaN(m.Score) {
+			return 0, errors.New("ERR value is not a valid float")
+		}
+		str, err := c.serializer.encode(m.Member)
+		if err != nil {
+			return 0, err
+		}
+		strs = append(strs, str)
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it != nil && it.kind != kindZSet {
+		return 0, errWrongType
+	}
+	if it == nil {
+		it = s.create(key, kindZSet)
+	}
+	var added int64
+	for i, member := range strs {
+		if _, ok := it.zset[member]; !ok {
+			added++
+		}
+		it.zset[member] = members[i].Score
+	}
+	return added, nil
+}
+
+func (c *MemoryClient) ZREM(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	if len(members) == 0 {
+		return 0, errWrongArgs("zrem")
+	}
+	strs, err := c.serializer.encodeAll(members)
+	if err != nil {
+		return 0, err
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return 0, nil
+	}
+	if it.kind != kindZSet {
+		return 0, errWrongType
+	}
+	var removed int64
+	for _, member := range strs {
+		if _, ok := it.zset[member]; ok {
+			delete(it.zset, member)
+			removed++
+		}
+	}
+	s.removeIfEmpty(it)
+	return removed, nil
+}
+
+func (c *MemoryClient) ZSCORE(ctx context.Context, key string, member interface{}) (float64, error) {
+	str, err := c.serializer.encode(member)
+	if err != nil {
+		return 0, err
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return 0, ErrNil
+	}
+	if it.kind != kindZSet {
+		return 0, errWrongType
+	}
+	score, ok := it.zset[str]
+	if !ok {
+		return 0, ErrNil
+	}
+	return score, nil
+}
+
+func (c *MemoryClient) ZCARD(ctx context.Context, key string) (int64, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return 0, nil
+	}
+	if it.kind != kindZSet {
+		return 0, errWrongType
+	}
+	return int64(len(it.zset)), nil
+}
+
+func (c *MemoryClient) ZRANGE(ctx context.Context, key string, start, stop int64) SliceResult {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return c.sliceResult(nil, nil)
+	}
+	if it.kind != kindZSet {
+		return c.sliceResult(nil, errWrongType)
+	}
+	members := it.sortedMembers()
+	from, to, ok := normalizeRange(start, stop, int64(len(members)))
+	if !ok {
+		return c.sliceResult(nil, nil)
+	}
+	return c.sliceResult(append([]string{}, members[from:to+1]...), nil)
+}
+
+func (c *MemoryClient) ZRANGEBYSCORE(ctx context.Context, key string, min, max float64) SliceResult {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return c.sliceResult(nil, nil)
+	}
+	if it.kind != kindZSet {
+		return c.sliceResult(nil, errWrongType)
+	}
+	vals := []string{}
+	for _, member := range it.sortedMembers() {
+		if score := it.zset[member]; score >= min && score <= max {
+			vals = append(vals, member)
+		}
+	}
+	return c.sliceResult(vals, nil)
+}
+
+func (c *MemoryClient) ZREMRANGEBYSCORE(ctx context.Context, key string, min, max float64) (int64, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return 0, nil
+	}
+	if it.kind != kindZSet {
+		return 0, errWrongType
+	}
+	var removed int64
+	for member, score := range it.zset {
+		if score >= min && score <= max {
+			delete(it.zset, member)
+			removed++
+		}
+	}
+	s.removeIfEmpty(it)
+	return removed, nil
+}
+
+func (c *MemoryClient) LPUSH(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	return c.push(key, EventLpush, values, true)
+}
+
+func (c *MemoryClient) RPUSH(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	return c.push(key, EventRpush, values, false)
+}
+
+func (c *MemoryClient) push(key, cmd string, values []interface{}, head bool) (int64, error) {
+	if len(values) == 0 {
+		return 0, errWrongArgs(cmd)
+	}
+	strs, err := c.serializer.encodeAll(values)
+	if err != nil {
+		return 0, err
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it != nil && it.kind != kindList {
+		return 0, errWrongType
+	}
+	if it == nil {
+		it = s.create(key, kindList)
+	}
+	if head {
+		// Every value is pushed to the head one after another, so the last value becomes the first.
+		reversed := make([]string, 0, len(strs)+len(it.list))
+		for i := len(strs) - 1; i >= 0; i-- {
+			reversed = append(reversed, strs[i])
+		}
+		it.list = append(reversed, it.list...)
+	} else {
+		it.list = append(it.list, strs...)
+	}
+	s.notify(cmd, key)
+	return int64(len(it.list)), nil
+}
+
+func (c *MemoryClient) LPOP(ctx context.Context, key string) StringResult {
+	return c.pop(key, true)
+}
+
+func (c *MemoryClient) RPOP(ctx context.Context, key string) StringResult {
+	return c.pop(key, false)
+}
+
+func (c *MemoryClient) pop(key string, head bool) StringResult {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return c.stringResult("", ErrNil)
+	}
+	if it.kind != kindList {
+		return c.stringResult("", errWrongType)
+	}
+	var val string
+	if head {
+		val, it.list = it.list[0], it.list[1:]
+	} else {
+		last := len(it.list) - 1
+		val, it.list = it.list[last], it.list[:last]
+	}
+	s.removeIfEmpty(it)
+	return c.stringResult(val, nil)
+}
+
+func (c *MemoryClient) LRANGE(ctx context.Context, key string, start, stop int64) SliceResult {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return c.sliceResult(nil, nil)
+	}
+	if it.kind != kindList {
+		return c.sliceResult(nil, errWrongType)
+	}
+	from, to, ok := normalizeRange(start, stop, int64(len(it.list)))
+	if !ok {
+		return c.sliceResult(nil, nil)
+	}
+	return c.sliceResult(append([]string{}, it.list[from:to+1]...), nil)
+}
+
+func (c *MemoryClient) LLEN(ctx context.Context, key string) (int64, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return 0, nil
+	}
+	if it.kind != kindList {
+		return 0, errWrongType
+	}
+	return int64(len(it.list)), nil
+}
+
+func (c *MemoryClient) LTRIM(ctx context.Context, key string, start, stop int64) error {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := s.lookup(key)
+	if it == nil {
+		return nil
+	}
+	if it.kind != kindList {
+		return errWrongType
+	}
+	from, to, ok := normalizeRange(start, stop, int64(len(it.list)))
+	if !ok {
+		s.remove(it)
+		return nil
+	}
+	it.list = append([]string{}, it.list[from:to+1]...)
+	return nil
+}
+
+// normalizeRange converts the inclusive Redis range, which may use the negative index, to the slice index.
+func normalizeRange(start, stop, n int64) (int64, int64, bool) {
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start > stop || start >= n {
+		return 0, 0, false
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	return start, stop, true
+}
+
+func nowMs() int64 {
+	return time.Now().UnixMilli()
+}
+
+type itemKind int
+
+const (
+	kindString itemKind = iota
+	kindHash
+	kindZSet
+	kindList
+)
+
+type memoryItem struct {
+	key      string
+	kind     itemKind
+	str      string
+	hash     map[string]string
+	zset     map[string]float64
+	list     []string
+	expireAt int64 // unix milliseconds, 0 means no expiration.
+	elem     *list.Element
+	freq     uint32
+}
+
+func (it *memoryItem) expired(now int64) bool {
+	return it.expireAt > 0 && now > it.expireAt
+}
+
+func (it *memoryItem) empty() bool {
+	switch it.kind {
+	case kindHash:
+		return len(it.hash) == 0
+	case kindZSet:
+		return len(it.zset) == 0
+	case kindList:
+		return len(it.list) == 0
+	}
+	return false
+}
+
+// sortedMembers returns the members ordered by score, then by member.
+func (it *memoryItem) sortedMembers() []string {
+	members := make([]string, 0, len(it.zset))
+	for member := range it.zset {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		si, sj := it.zset[members[i]], it.zset[members[j]]
+		if si != sj {
+			return si < sj
+		}
+		return members[i] < members[j]
+	})
+	return members
+}
+
+type memoryShard struct {
+	mu       sync.Mutex
+	items    map[string]*memoryItem
+	lru      *list.List // front is the most recently used.
+	maxKeys  int
+	eviction Eviction
+	// notify publishes the keyspace event of the key.
+	notify func(event, key string)
+}
+
+// lookup returns the live item of the key and marks it as used. It returns nil if the key does not exist.
+func (s *memoryShard) lookup(key string) *memoryItem {
+	it, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+	if it.expired(nowMs()) {
+		s.remove(it)
+		s.notify(EventExpired, key)
+		return nil
+	}
+	s.lru.MoveToFront(it.elem)
+	if it.freq < math.MaxUint32 {
+		it.freq++
+	}
+	return it
+}
+
+// create adds the empty item of the key, evicting another key when the shard is full.
+func (s *memoryShard) create(key string, kind itemKind) *memoryItem {
+	if s.maxKeys > 0 {
+		for len(s.items) >= s.maxKeys {
+			s.evict()
+		}
+	}
+	it := &memoryItem{key: key, kind: kind, freq: 1}
+	switch kind {
+	case kindHash:
+		it.hash = make(map[string]string)
+	case kindZSet:
+		it.zset = make(map[string]float64)
+	}
+	it.elem = s.lru.PushFront(it)
+	s.items[key] = it
+	return it
+}
+
+// setString replaces the key with the string value. See GoRedisClient.SET for the expiration.
+func (s *memoryShard) setString(key, val string, exp time.Duration) {
+	var expireAt int64
+	it := s.lookup(key)
+	if it != nil {
+		expireAt = it.expireAt
+		if it.kind != kindString {
+			s.remove(it)
+			it = nil
+		}
+	}
+	if it == nil {
+		it = s.create(key, kindString)
+	}
+	it.str = val
+
+	switch {
+	case exp > 0:
+		ms := exp.Milliseconds()
+		if ms == 0 {
+			ms = 1
+		}
+		it.expireAt = nowMs() + ms
+	case exp == redis.KeepTTL:
+		it.expireAt = expireAt
+	default:
+		it.expireAt = 0
+	}
+	s.notify(EventSet, key)
+}
+
+func (s *memoryShard) remove(it *memoryItem) {
+	s.lru.Remove(it.elem)
+	delete(s.items, it.key)
+}
+
+// removeIfEmpty removes the hash, sorted set or list without element, as Redis does.
+func (s *memoryShard) removeIfEmpty(it *memoryItem) {
+	if it.empty() {
+		s.remove(it)
+	}
+}
+
+func (s *memoryShard) evict() {
+	victim := s.lru.Back()
+	if victim == nil {
+		return
+	}
+	if s.eviction == EvictionLFU {
+		elem := victim
+		for i := 0; i < lfuSamples && elem != nil; i++ {
+			if elem.Value.(*memoryItem).freq < victim.Value.(*memoryItem).freq {
+				victim = elem
+			}
+			elem = elem.Prev()
+		}
+	}
+	it := victim.Value.(*memoryItem)
+	s.remove(it)
+	s.notify(EventEvicted, it.key)
+}
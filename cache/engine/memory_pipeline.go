@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+// Pipeline returns a new pipeline. The memory engine runs every pipeline atomically, the same as TxPipeline.
+func (c *MemoryClient) Pipeline() Pipeliner {
+	return &memoryPipeline{c: c}
+}
+
+func (c *MemoryClient) TxPipeline() Pipeliner {
+	return &memoryPipeline{c: c}
+}
+
+type memoryPipeline struct {
+	c *MemoryClient
+	// ops runs every queued command with all shards locked and returns its error.
+	ops []func() error
+}
+
+func (p *memoryPipeline) GET(ctx context.Context, key string) *StringCmd {
+	out := &StringCmd{}
+	p.ops = append(p.ops, func() error {
+		out.res = p.c.getLocked(key)
+		return out.res.err
+	})
+	return out
+}
+
+func (p *memoryPipeline) SET(ctx context.Context, key string, val interface{}, exp time.Duration) *StatusCmd {
+	out := &StatusCmd{}
+	str, err := p.c.serializer.encode(val)
+	p.ops = append(p.ops, func() error {
+		if err == nil {
+			p.c.shard(key).setString(key, str, exp)
+		}
+		out.err = err
+		return err
+	})
+	return out
+}
+
+func (p *memoryPipeline) SETNX(ctx context.Context, key string, val interface{}, exp time.Duration) *Cmd[bool] {
+	out := &Cmd[bool]{}
+	str, err := p.c.serializer.encode(val)
+	p.ops = append(p.ops, func() error {
+		if err != nil {
+			out.err = err
+			return err
+		}
+		out.val, out.err = p.c.setnxLocked(key, str, exp)
+		return out.err
+	})
+	return out
+}
+
+func (p *memoryPipeline) HSET(ctx context.Context, key string, fields map[string]interface{}) *StatusCmd {
+	out := &StatusCmd{}
+	values := make(map[string]string, len(fields))
+	var err error
+	if len(fields) == 0 {
+		err = errWrongArgs("hset")
+	}
+	for field, val := range fields {
+		if values[field], err = p.c.serializer.encode(val); err != nil {
+			break
+		}
+	}
+	p.ops = append(p.ops, func() error {
+		if err == nil {
+			err = p.c.hsetLocked(key, values)
+		}
+		out.err = err
+		return err
+	})
+	return out
+}
+
+func (p *memoryPipeline) HGET(ctx context.Context, key string, field string) *StringCmd {
+	out := &StringCmd{}
+	p.ops = append(p.ops, func() error {
+		out.res = p.c.hgetLocked(key, field)
+		return out.res.err
+	})
+	return out
+}
+
+func (p *memoryPipeline) HGETALL(ctx context.Context, key string) *Cmd[map[string]string] {
+	out := &Cmd[map[string]string]{}
+	p.ops = append(p.ops, func() error {
+		out.val, out.err = p.c.hgetallLocked(key)
+		return out.err
+	})
+	return out
+}
+
+func (p *memoryPipeline) DEL(ctx context.Context, keys ...string) *StatusCmd {
+	out := &StatusCmd{}
+	p.ops = append(p.ops, func() error {
+		if len(keys) == 0 {
+			out.err = errWrongArgs("del")
+		} else {
+			out.err = p.c.delLocked(keys)
+		}
+		return out.err
+	})
+	return out
+}
+
+func (p *memoryPipeline) EXISTS(ctx context.Context, keys ...string) *Cmd[int64] {
+	out := &Cmd[int64]{}
+	p.ops = append(p.ops, func() error {
+		if len(keys) == 0 {
+			out.err = errWrongArgs("exists")
+		} else {
+			out.val, out.err = p.c.existsLocked(keys)
+		}
+		return out.err
+	})
+	return out
+}
+
+func (p *memoryPipeline) INCRBY(ctx context.Context, key string, value int64, exp time.Duration) *Cmd[int64] {
+	out := &Cmd[int64]{}
+	p.ops = append(p.ops, func() error {
+		out.val, out.err = p.c.incrbyLocked(key, value, exp)
+		return out.err
+	})
+	return out
+}
+
+func (p *memoryPipeline) EXPIRE(ctx context.Context, key string, exp time.Duration) *Cmd[bool] {
+	out := &Cmd[bool]{}
+	p.ops = append(p.ops, func() error {
+		out.val, out.err = p.c.expireLocked(key, exp)
+		return out.err
+	})
+	return out
+}
+
+func (p *memoryPipeline) TTL(ctx context.Context, key string) *Cmd[time.Duration] {
+	out := &Cmd[time.Duration]{}
+	p.ops = append(p.ops, func() error {
+		out.val, out.err = p.c.ttlLocked(key)
+		return out.err
+	})
+	return out
+}
+
+func (p *memoryPipeline) Len() int {
+	return len(p.ops)
+}
+
+func (p *memoryPipeline) Exec(ctx context.Context) error {
+	ops := p.ops
+	p.ops = nil
+
+	unlock := p.c.lockAll()
+	defer unlock()
+
+	errs := make([]error, 0, len(ops))
+	for _, op := range ops {
+		errs = append(errs, op())
+	}
+	return firstErr(errs...)
+}
+
+func (p *memoryPipeline) Discard() {
+	p.ops = nil
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryPubSub delivers the published messages to the subscriptions of the same MemoryClient.
+type memoryPubSub struct {
+	mu   sync.RWMutex
+	subs map[*memorySubscription]struct{}
+}
+
+// publish sends the message to every matching subscription and returns the number of them receiving it.
+// When wait is true, the message waits for the subscription that is not keeping up until ctx is done.
+// Otherwise the message is dropped for it, so the publisher never blocks. The dropped message is not counted,
+// and the error of ctx is returned when the message is dropped because ctx is done.
+func (ps *memoryPubSub) publish(ctx context.Context, channel, payload string, ser serializer, wait bool) (int64, error) {
+	decoded, err := ser.decode(payload)
+	if err != nil {
+		decoded = payload
+	}
+
+	// The subscriptions are collected first, so the slow subscription does not block subscribe and Close.
+	type target struct {
+		sub     *memorySubscription
+		pattern string
+	}
+	var targets []target
+	ps.mu.RLock()
+	for sub := range ps.subs {
+		if pattern, ok := sub.match(channel); ok {
+			targets = append(targets, target{sub: sub, pattern: pattern})
+		}
+	}
+	ps.mu.RUnlock()
+
+	var n int64
+	for _, t := range targets {
+		msg := &Message{
+			Channel:   channel,
+			Pattern:   t.pattern,
+			Payload:   decoded,
+			err:       err,
+			unmarshal: ser.scan,
+		}
+		if t.sub.send(ctx, msg, wait) {
+			n++
+		}
+	}
+	if wait && n < int64(len(targets)) && ctx.Err() != nil {
+		return n, ctx.Err()
+	}
+	return n, nil
+}
+
+func (ps *memoryPubSub) subscribe(channels, patterns []string) *memorySubscription {
+	sub := &memorySubscription{
+		ps:       ps,
+		channels: make(map[string]bool, len(channels)),
+		patterns: patterns,
+		ch:       make(chan *Message, subscriptionChannelSize),
+		done:     make(chan struct{}),
+	}
+	for _, channel := range channels {
+		sub.channels[channel] = true
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.subs == nil {
+		ps.subs = make(map[*memorySubscription]struct{})
+	}
+	ps.subs[sub] = struct{}{}
+	return sub
+}
+
+type memorySubscription struct {
+	ps       *memoryPubSub
+	channels map[string]bool
+	patterns []string
+	ch       chan *Message
+	once     sync.Once
+
+	// done is closed first on Close to stop the waiting publisher.
+	done chan struct{}
+	// mu guards closed, so the message is never sent on the closed channel.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// send sends the message to the subscription and reports whether it is received.
+func (s *memorySubscription) send(ctx context.Context, msg *Message, wait bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return false
+	}
+	if !wait {
+		select {
+		case s.ch <- msg:
+			return true
+		default:
+			return false
+		}
+	}
+	select {
+	case s.ch <- msg:
+		return true
+	case <-s.done:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// match returns the pattern matching the channel, which is empty when the channel is subscribed by name.
+func (s *memorySubscription) match(channel string) (string, bool) {
+	if s.channels[channel] {
+		return "", true
+	}
+	for _, pattern := range s.patterns {
+		if matchPattern(pattern, channel) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+func (s *memorySubscription) Channel() <-chan *Message {
+	return s.ch
+}
+
+func (s *memorySubscription) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+		s.ps.mu.Lock()
+		delete(s.ps.subs, s)
+		s.ps.mu.Unlock()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed = true
+		close(s.ch)
+	})
+	return nil
+}
+
+// PUBLISH waits for the subscriber whose buffer is full until ctx is done,
+// and returns the number of the subscribers receiving the message.
+func (c *MemoryClient) PUBLISH(ctx context.Context, channel string, msg interface{}) (int64, error) {
+	str, err := c.serializer.encode(msg)
+	if err != nil {
+		return 0, err
+	}
+	return c.pubsub.publish(ctx, channel, str, c.serializer, true)
+}
+
+func (c *MemoryClient) SUBSCRIBE(ctx context.Context, channels ...string) (Subscription, error) {
+	if len(channels) == 0 {
+		return nil, errWrongArgs("subscribe")
+	}
+	return c.pubsub.subscribe(channels, nil), nil
+}
+
+func (c *MemoryClient) PSUBSCRIBE(ctx context.Context, patterns ...string) (Subscription, error) {
+	if len(patterns) == 0 {
+		return nil, errWrongArgs("psubscribe")
+	}
+	return c.pubsub.subscribe(nil, patterns), nil
+}
+
+// notify publishes the keyspace event of the key when MemoryClientOpt.KeyspaceEvents is set.
+// It is called with the shard locked, so unlike PUBLISH it never blocks, and the event is dropped for the subscriber
+// whose buffer is full.
+func (c *MemoryClient) notify(event, key string) {
+	if c.opt.KeyspaceEvents {
+		_, _ = c.pubsub.publish(context.Background(), keyEventChannel(0, event), key, c.serializer, false)
+	}
+}
@@ -0,0 +1,267 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestMemoryClient(t *testing.T, opt MemoryClientOpt) *MemoryClient {
+	t.Helper()
+	cli, err := NewMemoryClient(opt)
+	if err != nil {
+		t.Fatalf("NewMemoryClient() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+	return cli
+}
+
+type memoryTestValue struct {
+	Name string `json:"name"`
+}
+
+func TestMemoryClient_String(t *testing.T) {
+	cli := newTestMemoryClient(t, MemoryClientOpt{Shards: 4})
+	ctx := context.Background()
+
+	if err := cli.GET(ctx, "missing").Err(); err != ErrNil {
+		t.Fatalf("GET() error = %v, want %v", err, ErrNil)
+	}
+	if err := cli.SET(ctx, "struct", memoryTestValue{Name: "gdk"}, 0); err != nil {
+		t.Fatalf("SET() failed: %v", err)
+	}
+	var got memoryTestValue
+	if err := cli.GET(ctx, "struct").Scan(&got); err != nil || got.Name != "gdk" {
+		t.Fatalf("GET().Scan() = %+v, %v, want gdk", got, err)
+	}
+
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{val: "value", want: "value"},
+		{val: 10, want: "10"},
+		{val: 1.5, want: "1.5"},
+		{val: true, want: "1"},
+		{val: []byte("raw"), want: "raw"},
+		{val: time.Second, want: "1000000000"},
+	}
+	for _, tt := range tests {
+		if err := cli.SET(ctx, "key", tt.val, 0); err != nil {
+			t.Fatalf("SET(%v) failed: %v", tt.val, err)
+		}
+		if got := cli.GET(ctx, "key").Value(); got != tt.want {
+			t.Errorf("GET() after SET(%v) = %q, want %q", tt.val, got, tt.want)
+		}
+	}
+
+	ok, err := cli.SETNX(ctx, "key", "other", 0)
+	if err != nil || ok {
+		t.Fatalf("SETNX() on existing key = %v, %v, want false", ok, err)
+	}
+	results := cli.MGET(ctx, "key", "missing")
+	if results[0].Value() != "1000000000" || results[1].Err() != ErrNil {
+		t.Errorf("MGET() = %v, %v", results[0].Value(), results[1].Err())
+	}
+	if n, _ := cli.EXISTS(ctx, "key", "key", "missing"); n != 2 {
+		t.Errorf("EXISTS() = %d, want 2", n)
+	}
+	if err := cli.DEL(ctx, "key"); err != nil {
+		t.Fatalf("DEL() failed: %v", err)
+	}
+	if n, _ := cli.EXISTS(ctx, "key"); n != 0 {
+		t.Errorf("EXISTS() after DEL() = %d, want 0", n)
+	}
+}
+
+func TestMemoryClient_TTL(t *testing.T) {
+	cli := newTestMemoryClient(t, MemoryClientOpt{})
+	ctx := context.Background()
+
+	if ttl, _ := cli.TTL(ctx, "missing"); ttl != TTLKeyNotExist {
+		t.Errorf("TTL() of missing key = %v, want %v", ttl, TTLKeyNotExist)
+	}
+	_ = cli.SET(ctx, "key", "value", 0)
+	if ttl, _ := cli.TTL(ctx, "key"); ttl != TTLNoExpiration {
+		t.Errorf("TTL() without expiration = %v, want %v", ttl, TTLNoExpiration)
+	}
+
+	_ = cli.SET(ctx, "key", "value", 20*time.Millisecond)
+	if ttl, _ := cli.TTL(ctx, "key"); ttl <= 0 || ttl > 20*time.Millisecond {
+		t.Errorf("TTL() = %v, want within 20ms", ttl)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if err := cli.GET(ctx, "key").Err(); err != ErrNil {
+		t.Errorf("GET() of expired key error = %v, want %v", err, ErrNil)
+	}
+
+	if ok, _ := cli.EXPIRE(ctx, "missing", time.Minute); ok {
+		t.Error("EXPIRE() of missing key = true, want false")
+	}
+	_ = cli.SET(ctx, "key", "value", 0)
+	if ok, _ := cli.EXPIRE(ctx, "key", 0); !ok {
+		t.Error("EXPIRE() = false, want true")
+	}
+	if n, _ := cli.EXISTS(ctx, "key"); n != 0 {
+		t.Errorf("EXISTS() after EXPIRE(0) = %d, want 0", n)
+	}
+}
+
+func TestMemoryClient_Counter(t *testing.T) {
+	cli := newTestMemoryClient(t, MemoryClientOpt{})
+	ctx := context.Background()
+
+	if v, err := cli.INCRBY(ctx, "counter", 5, time.Minute); err != nil || v != 5 {
+		t.Fatalf("INCRBY() = %d, %v, want 5", v, err)
+	}
+	ttl, _ := cli.TTL(ctx, "counter")
+	if v, _ := cli.DECR(ctx, "counter", time.Hour); v != 4 {
+		t.Errorf("DECR() = %d, want 4", v)
+	}
+	if got, _ := cli.TTL(ctx, "counter"); got > ttl {
+		t.Errorf("TTL() after DECR() = %v, want the expiration kept at %v", got, ttl)
+	}
+
+	_ = cli.SET(ctx, "text", "abc", 0)
+	if _, err := cli.INCR(ctx, "text", 0); err != errNotInteger {
+		t.Errorf("INCR() of text error = %v, want %v", err, errNotInteger)
+	}
+	_ = cli.HSET(ctx, "hash", map[string]interface{}{"field": 1})
+	if _, err := cli.INCR(ctx, "hash", 0); err != errWrongType {
+		t.Errorf("INCR() of hash error = %v, want %v", err, errWrongType)
+	}
+}
+
+func TestMemoryClient_Hash(t *testing.T) {
+	cli := newTestMemoryClient(t, MemoryClientOpt{})
+	ctx := context.Background()
+
+	if err := cli.HSET(ctx, "hash", map[string]interface{}{"a": 1, "b": "two"}); err != nil {
+		t.Fatalf("HSET() failed: %v", err)
+	}
+	if got := cli.HGET(ctx, "hash", "a").Value(); got != "1" {
+		t.Errorf("HGET() = %q, want 1", got)
+	}
+	if err := cli.HGET(ctx, "hash", "c").Err(); err != ErrNil {
+		t.Errorf("HGET() of missing field error = %v, want %v", err, ErrNil)
+	}
+	got, err := cli.HGETALL(ctx, "hash")
+	if want := map[string]string{"a": "1", "b": "two"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("HGETALL() = %v, %v, want %v", got, err, want)
+	}
+	if err := cli.GET(ctx, "hash").Err(); err != errWrongType {
+		t.Errorf("GET() of hash error = %v, want %v", err, errWrongType)
+	}
+	if got, err := cli.HGETALL(ctx, "missing"); err != nil || len(got) != 0 {
+		t.Errorf("HGETALL() of missing key = %v, %v, want empty", got, err)
+	}
+}
+
+func TestMemoryClient_SortedSet(t *testing.T) {
+	cli := newTestMemoryClient(t, MemoryClientOpt{})
+	ctx := context.Background()
+
+	n, err := cli.ZADD(ctx, "zset", Z{Score: 2, Member: "b"}, Z{Score: 1, Member: "a"}, Z{Score: 2, Member: "c"})
+	if err != nil || n != 3 {
+		t.Fatalf("ZADD() = %d, %v, want 3", n, err)
+	}
+	if n, _ := cli.ZADD(ctx, "zset", Z{Score: 3, Member: "a"}); n != 0 {
+		t.Errorf("ZADD() of existing member = %d, want 0", n)
+	}
+
+	tests := []struct {
+		name string
+		got  SliceResult
+		want []string
+	}{
+		{name: "all", got: cli.ZRANGE(ctx, "zset", 0, -1), want: []string{"b", "c", "a"}},
+		{name: "negative", got: cli.ZRANGE(ctx, "zset", -2, -1), want: []string{"c", "a"}},
+		{name: "out of range", got: cli.ZRANGE(ctx, "zset", 5, 10), want: []string{}},
+		{name: "by score", got: cli.ZRANGEBYSCORE(ctx, "zset", 2, 2), want: []string{"b", "c"}},
+		{name: "missing", got: cli.ZRANGE(ctx, "missing", 0, -1), want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.Err() != nil || !reflect.DeepEqual(tt.got.Values(), tt.want) {
+				t.Errorf("got %v, %v, want %v", tt.got.Values(), tt.got.Err(), tt.want)
+			}
+		})
+	}
+
+	if score, err := cli.ZSCORE(ctx, "zset", "a"); err != nil || score != 3 {
+		t.Errorf("ZSCORE() = %v, %v, want 3", score, err)
+	}
+	if n, _ := cli.ZREMRANGEBYSCORE(ctx, "zset", 0, 2); n != 2 {
+		t.Errorf("ZREMRANGEBYSCORE() = %d, want 2", n)
+	}
+	if n, _ := cli.ZREM(ctx, "zset", "a", "x"); n != 1 {
+		t.Errorf("ZREM() = %d, want 1", n)
+	}
+	if n, _ := cli.EXISTS(ctx, "zset"); n != 0 {
+		t.Errorf("EXISTS() of empty sorted set = %d, want 0", n)
+	}
+}
+
+func TestMemoryClient_List(t *testing.T) {
+	cli := newTestMemoryClient(t, MemoryClientOpt{})
+	ctx := context.Background()
+
+	if n, _ := cli.RPUSH(ctx, "list", "c", "d"); n != 2 {
+		t.Errorf("RPUSH() = %d, want 2", n)
+	}
+	if n, _ := cli.LPUSH(ctx, "list", "b", "a"); n != 4 {
+		t.Errorf("LPUSH() = %d, want 4", n)
+	}
+	if got := cli.LRANGE(ctx, "list", 0, -1).Values(); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("LRANGE() = %v", got)
+	}
+	if got := cli.LPOP(ctx, "list").Value(); got != "a" {
+		t.Errorf("LPOP() = %q, want a", got)
+	}
+	if got := cli.RPOP(ctx, "list").Value(); got != "d" {
+		t.Errorf("RPOP() = %q, want d", got)
+	}
+	if err := cli.LTRIM(ctx, "list", 1, -1); err != nil {
+		t.Fatalf("LTRIM() failed: %v", err)
+	}
+	if n, _ := cli.LLEN(ctx, "list"); n != 1 {
+		t.Errorf("LLEN() = %d, want 1", n)
+	}
+	_ = cli.LTRIM(ctx, "list", 5, 10)
+	if err := cli.LPOP(ctx, "list").Err(); err != ErrNil {
+		t.Errorf("LPOP() of trimmed list error = %v, want %v", err, ErrNil)
+	}
+}
+
+func TestMemoryClient_Eviction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("LRU", func(t *testing.T) {
+		cli := newTestMemoryClient(t, MemoryClientOpt{MaxKeys: 2})
+		_ = cli.SET(ctx, "a", 1, 0)
+		_ = cli.SET(ctx, "b", 2, 0)
+		_ = cli.GET(ctx, "a")
+		_ = cli.SET(ctx, "c", 3, 0)
+		if err := cli.GET(ctx, "b").Err(); err != ErrNil {
+			t.Errorf("GET() of least recently used key error = %v, want %v", err, ErrNil)
+		}
+		if n, _ := cli.EXISTS(ctx, "a", "c"); n != 2 {
+			t.Errorf("EXISTS() = %d, want 2", n)
+		}
+	})
+
+	t.Run("LFU", func(t *testing.T) {
+		cli := newTestMemoryClient(t, MemoryClientOpt{MaxKeys: 2, Eviction: EvictionLFU})
+		_ = cli.SET(ctx, "a", 1, 0)
+		_ = cli.SET(ctx, "b", 2, 0)
+		for i := 0; i < 3; i++ {
+			_ = cli.GET(ctx, "a")
+		}
+		_ = cli.GET(ctx, "b")
+		_ = cli.SET(ctx, "c", 3, 0)
+		if err := cli.GET(ctx, "b").Err(); err != ErrNil {
+			t.Errorf("GET() of least frequently used key error = %v, want %v", err, ErrNil)
+		}
+	})
+}
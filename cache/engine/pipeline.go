@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Pipeliner queues the commands and sends them in one round trip on Exec.
+// The result of every command is available after Exec returns.
+//
+// The pipeline of Pipeline is not atomic, the other clients can run their commands in between.
+// The pipeline of TxPipeline is wrapped in MULTI/EXEC, so the commands run atomically.
+type Pipeliner interface {
+	GET(ctx context.Context, key string) *StringCmd
+	SET(ctx context.Context, key string, val interface{}, exp time.Duration) *StatusCmd
+	SETNX(ctx context.Context, key string, val interface{}, exp time.Duration) *Cmd[bool]
+	HSET(ctx context.Context, key string, fields map[string]interface{}) *StatusCmd
+	HGET(ctx context.Context, key string, field string) *StringCmd
+	HGETALL(ctx context.Context, key string) *Cmd[map[string]string]
+	DEL(ctx context.Context, keys ...string) *StatusCmd
+	EXISTS(ctx context.Context, keys ...string) *Cmd[int64]
+	INCRBY(ctx context.Context, key string, value int64, exp time.Duration) *Cmd[int64]
+	EXPIRE(ctx context.Context, key string, exp time.Duration) *Cmd[bool]
+	TTL(ctx context.Context, key string) *Cmd[time.Duration]
+
+	// Len returns the number of the queued commands.
+	Len() int
+	// Exec sends the queued commands and empties the queue. It returns the first error of the commands
+	// other than ErrNil, and the error of every command is kept on its result.
+	Exec(ctx context.Context) error
+	// Discard empties the queue without sending the commands.
+	Discard()
+}
+
+// Cmd is the result of a queued command.
+type Cmd[T any] struct {
+	val T
+	err error
+}
+
+func (c *Cmd[T]) Val() T {
+	return c.val
+}
+
+func (c *Cmd[T]) Err() error {
+	return c.err
+}
+
+func (c *Cmd[T]) Result() (T, error) {
+	return c.val, c.err
+}
+
+// StatusCmd is the result of a queued command that returns no value.
+type StatusCmd struct {
+	err error
+}
+
+func (c *StatusCmd) Err() error {
+	return c.err
+}
+
+// StringCmd is the result of a queued command that returns a string.
+type StringCmd struct {
+	res StringResult
+}
+
+func (c *StringCmd) Value() string {
+	return c.res.Value()
+}
+
+func (c *StringCmd) Err() error {
+	return c.res.Err()
+}
+
+func (c *StringCmd) Scan(dest interface{}) error {
+	return c.res.Scan(dest)
+}
+
+// firstErr returns the first error other than ErrNil.
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, ErrNil) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryPipeline(t *testing.T) {
+	ctx := context.Background()
+	cli := newTestMemoryClient(t, MemoryClientOpt{Shards: 4})
+	_ = cli.SET(ctx, "text", "abc", 0)
+
+	pipe := cli.Pipeline()
+	set := pipe.SET(ctx, "key", memoryTestValue{Name: "gdk"}, time.Minute)
+	get := pipe.GET(ctx, "key")
+	missing := pipe.GET(ctx, "missing")
+	hset := pipe.HSET(ctx, "hash", map[string]interface{}{"a": 1})
+	hgetall := pipe.HGETALL(ctx, "hash")
+	incr := pipe.INCRBY(ctx, "counter", 2, 0)
+	bad := pipe.INCRBY(ctx, "text", 1, 0)
+	exists := pipe.EXISTS(ctx, "key", "hash", "missing")
+	ttl := pipe.TTL(ctx, "key")
+	if pipe.Len() != 9 {
+		t.Fatalf("Len() = %d, want 9", pipe.Len())
+	}
+	if get.Err() != nil || get.Value() != "" {
+		t.Errorf("result before Exec() = %q, %v, want empty", get.Value(), get.Err())
+	}
+
+	if err := pipe.Exec(ctx); err != errNotInteger {
+		t.Fatalf("Exec() error = %v, want %v", err, errNotInteger)
+	}
+	if pipe.Len() != 0 {
+		t.Errorf("Len() after Exec() = %d, want 0", pipe.Len())
+	}
+
+	var value memoryTestValue
+	if err := get.Scan(&value); set.Err() != nil || err != nil || value.Name != "gdk" {
+		t.Errorf("GET() = %+v, %v, want gdk", value, err)
+	}
+	if missing.Err() != ErrNil {
+		t.Errorf("GET() of missing key error = %v, want %v", missing.Err(), ErrNil)
+	}
+	if fields := hgetall.Val(); hset.Err() != nil || fields["a"] != "1" {
+		t.Errorf("HGETALL() = %v, want a=1", fields)
+	}
+	if v, err := incr.Result(); err != nil || v != 2 {
+		t.Errorf("INCRBY() = %d, %v, want 2", v, err)
+	}
+	if bad.Err() != errNotInteger {
+		t.Errorf("INCRBY() of text error = %v, want %v", bad.Err(), errNotInteger)
+	}
+	if exists.Val() != 2 {
+		t.Errorf("EXISTS() = %d, want 2", exists.Val())
+	}
+	if ttl.Val() <= 0 || ttl.Val() > time.Minute {
+		t.Errorf("TTL() = %v, want within 1 minute", ttl.Val())
+	}
+}
+
+func TestMemoryPipeline_Discard(t *testing.T) {
+	ctx := context.Background()
+	cli := newTestMemoryClient(t, MemoryClientOpt{})
+
+	pipe := cli.TxPipeline()
+	pipe.SET(ctx, "key", "value", 0)
+	pipe.Discard()
+	if err := pipe.Exec(ctx); err != nil {
+		t.Fatalf("Exec() failed: %v", err)
+	}
+	if n, _ := cli.EXISTS(ctx, "key"); n != 0 {
+		t.Errorf("EXISTS() after Discard() = %d, want 0", n)
+	}
+}
+
+func TestMemoryPipeline_Atomic(t *testing.T) {
+	ctx := context.Background()
+	cli := newTestMemoryClient(t, MemoryClientOpt{Shards: 4})
+
+	// Every transaction moves one unit between the keys, so their sum never changes.
+	_ = cli.SET(ctx, "a", 100, 0)
+	_ = cli.SET(ctx, "b", 0, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			pipe := cli.TxPipeline()
+			pipe.INCRBY(ctx, "a", -1, 0)
+			pipe.INCRBY(ctx, "b", 1, 0)
+			_ = pipe.Exec(ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			pipe := cli.TxPipeline()
+			a := pipe.GET(ctx, "a")
+			b := pipe.GET(ctx, "b")
+			_ = pipe.Exec(ctx)
+			var x, y int
+			_ = a.Scan(&x)
+			_ = b.Scan(&y)
+			if x+y != 100 {
+				t.Errorf("a + b = %d, want 100", x+y)
+			}
+		}()
+	}
+	wg.Wait()
+}
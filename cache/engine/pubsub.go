@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The keyspace events. Redis sends many more, e.g. hdel, lpop and zadd, while the memory engine only
+// sends these ones. See https://redis.io/docs/latest/develop/use/keyspace-notifications.
+const (
+	EventSet     = "set"
+	EventDel     = "del"
+	EventExpire  = "expire"
+	EventExpired = "expired"
+	EventEvicted = "evicted"
+	EventHset    = "hset"
+	EventLpush   = "lpush"
+	EventRpush   = "rpush"
+)
+
+// subscriptionChannelSize is the number of the received messages buffered by a subscription, the same as go-redis.
+const subscriptionChannelSize = 100
+
+// Subscription receives the messages of the subscribed channels.
+type Subscription interface {
+	// Channel returns the channel of the received messages. It is closed after Close.
+	Channel() <-chan *Message
+	// Close unsubscribes from every channel.
+	Close() error
+}
+
+// Message is the message received by a subscription.
+type Message struct {
+	Channel string
+	// Pattern is the pattern matching the channel, empty when the channel is subscribed by SUBSCRIBE.
+	Pattern string
+	Payload string
+
+	err       error
+	unmarshal func(val string, dest interface{}) error
+}
+
+// Err returns the error of decoding the payload.
+func (m *Message) Err() error {
+	return m.err
+}
+
+// Scan decodes the payload into dest.
+func (m *Message) Scan(dest interface{}) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.unmarshal(m.Payload, dest)
+}
+
+// KeyEvent returns the event and the key of the keyspace event message.
+// It returns false when the message is not sent to the channel of KeyEventPattern.
+func (m *Message) KeyEvent() (event, key string, ok bool) {
+	if !strings.HasPrefix(m.Channel, "__keyevent@") {
+		return "", "", false
+	}
+	i := strings.Index(m.Channel, "__:")
+	if i < 0 {
+		return "", "", false
+	}
+	return m.Channel[i+3:], m.Payload, true
+}
+
+// KeyEventPattern returns the pattern of PSUBSCRIBE matching the keyspace event of every database,
+// and the message payload is the key. Use "*" to match every event.
+//
+// Redis only sends the keyspace event when notify-keyspace-events is configured, e.g. "Exe" for the expired keys,
+// and the memory engine only sends it when MemoryClientOpt.KeyspaceEvents is set.
+// The Redis Cluster sends the event to the subscribers connected to the node holding the key.
+func KeyEventPattern(event string) string {
+	return "__keyevent@*__:" + event
+}
+
+func keyEventChannel(db int, event string) string {
+	return "__keyevent@" + strconv.Itoa(db) + "__:" + event
+}
+
+// matchPattern reports whether s matches the glob-style pattern of PSUBSCRIBE.
+// Same as Redis, it supports *, ?, [abc], [^abc], [a-z] and \ to escape the special character.
+func matchPattern(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchPattern(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			p := pattern[1:]
+			not := len(p) > 0 && p[0] == '^'
+			if not {
+				p = p[1:]
+			}
+			matched := false
+			for len(p) > 0 && p[0] != ']' {
+				switch {
+				case p[0] == '\\' && len(p) >= 2:
+					matched = matched || p[1] == s[0]
+					p = p[2:]
+				case len(p) >= 3 && p[1] == '-' && p[2] != ']':
+					lo, hi := p[0], p[2]
+					if lo > hi {
+						lo, hi = hi, lo
+					}
+					matched = matched || (s[0] >= lo && s[0] <= hi)
+					p = p[3:]
+				default:
+					matched = matched || p[0] == s[0]
+					p = p[1:]
+				}
+			}
+			if matched == not {
+				return false
+			}
+			// The class without the closing bracket ends at the end of the pattern.
+			if len(p) > 0 {
+				p = p[1:]
+			}
+			pattern, s = p, s[1:]
+			continue
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
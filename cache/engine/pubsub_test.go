@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, sub Subscription) *Message {
+	t.Helper()
+	select {
+	case msg, ok := <-sub.Channel():
+		if !ok {
+			t.Fatal("subscription is closed")
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message is received")
+	}
+	return nil
+}
+
+func TestMemoryClient_PubSub(t *testing.T) {
+	ctx := context.Background()
+	cli := newTestMemoryClient(t, MemoryClientOpt{})
+
+	sub, err := cli.SUBSCRIBE(ctx, "user")
+	if err != nil {
+		t.Fatalf("SUBSCRIBE() failed: %v", err)
+	}
+	psub, err := cli.PSUBSCRIBE(ctx, "user*")
+	if err != nil {
+		t.Fatalf("PSUBSCRIBE() failed: %v", err)
+	}
+
+	if n, err := cli.PUBLISH(ctx, "user", &memoryTestValue{Name: "gdk"}); err != nil || n != 2 {
+		t.Fatalf("PUBLISH() = %d, %v, want 2", n, err)
+	}
+	var got memoryTestValue
+	if msg := receive(t, sub); msg.Channel != "user" || msg.Pattern != "" || msg.Scan(&got) != nil || got.Name != "gdk" {
+		t.Errorf("message of SUBSCRIBE = %+v, %+v", msg, got)
+	}
+	if msg := receive(t, psub); msg.Channel != "user" || msg.Pattern != "user*" {
+		t.Errorf("message of PSUBSCRIBE = %+v, want pattern user*", msg)
+	}
+
+	_ = sub.Close()
+	if _, ok := <-sub.Channel(); ok {
+		t.Error("Channel() is not closed after Close()")
+	}
+	if n, _ := cli.PUBLISH(ctx, "users", "gdk"); n != 1 {
+		t.Errorf("PUBLISH() after Close() = %d, want 1", n)
+	}
+	if _, err := cli.SUBSCRIBE(ctx); err == nil {
+		t.Error("SUBSCRIBE() without channel error = nil")
+	}
+}
+
+func TestMemoryClient_KeyspaceEvents(t *testing.T) {
+	ctx := context.Background()
+	cli := newTestMemoryClient(t, MemoryClientOpt{KeyspaceEvents: true, MaxKeys: 1})
+
+	sub, err := cli.PSUBSCRIBE(ctx, KeyEventPattern("*"))
+	if err != nil {
+		t.Fatalf("PSUBSCRIBE() failed: %v", err)
+	}
+	_ = cli.SET(ctx, "a", "1", 10*time.Millisecond)
+	_, _ = cli.EXPIRE(ctx, "a", time.Second)
+	_ = cli.DEL(ctx, "a")
+	_ = cli.SET(ctx, "b", "1", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	_ = cli.GET(ctx, "b")
+	_ = cli.SET(ctx, "c", "1", 0)
+	_ = cli.SET(ctx, "d", "1", 0)
+	_ = cli.DEL(ctx, "d")
+	_ = cli.HSET(ctx, "h", map[string]interface{}{"f": "1"})
+	_ = cli.DEL(ctx, "h")
+	_, _ = cli.LPUSH(ctx, "l", "1")
+	_, _ = cli.RPUSH(ctx, "l", "2")
+
+	want := [][2]string{
+		{EventSet, "a"}, {EventExpire, "a"}, {EventDel, "a"},
+		{EventSet, "b"}, {EventExpired, "b"},
+		{EventSet, "c"}, {EventEvicted, "c"}, {EventSet, "d"}, {EventDel, "d"},
+		{EventHset, "h"}, {EventDel, "h"}, {EventLpush, "l"}, {EventRpush, "l"},
+	}
+	for _, w := range want {
+		event, key, ok := receive(t, sub).KeyEvent()
+		if !ok || event != w[0] || key != w[1] {
+			t.Errorf("KeyEvent() = %s %s %v, want %s %s", event, key, ok, w[0], w[1])
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{pattern: "*", s: "", want: true},
+		{pattern: "user:*", s: "user:1", want: true},
+		{pattern: "user:*", s: "order:1", want: false},
+		{pattern: "h?llo", s: "hello", want: true},
+		{pattern: "h?llo", s: "hllo", want: false},
+		{pattern: "h[ae]llo", s: "hallo", want: true},
+		{pattern: "h[^e]llo", s: "hello", want: false},
+		{pattern: "h[a-c]llo", s: "hbllo", want: true},
+		{pattern: `h\*llo`, s: "h*llo", want: true},
+		{pattern: `h\*llo`, s: "hello", want: false},
+		{pattern: "__keyevent@*__:expired", s: "__keyevent@0__:expired", want: true},
+	}
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryClient_PublishSlowSubscriber(t *testing.T) {
+	ctx := context.Background()
+	cli := newTestMemoryClient(t, MemoryClientOpt{})
+
+	sub, err := cli.SUBSCRIBE(ctx, "user")
+	if err != nil {
+		t.Fatalf("SUBSCRIBE() failed: %v", err)
+	}
+	for i := 0; i < subscriptionChannelSize; i++ {
+		if n, err := cli.PUBLISH(ctx, "user", i); err != nil || n != 1 {
+			t.Fatalf("PUBLISH() = %d, %v, want 1", n, err)
+		}
+	}
+
+	// The buffer is full, so the message is dropped when ctx is done, and it is not counted.
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if n, err := cli.PUBLISH(timeout, "user", "dropped"); err != context.DeadlineExceeded || n != 0 {
+		t.Errorf("PUBLISH() to full subscriber = %d, %v, want 0, %v", n, err, context.DeadlineExceeded)
+	}
+
+	// The publisher waits until the subscriber receives.
+	published := make(chan int64, 1)
+	go func() {
+		n, _ := cli.PUBLISH(ctx, "user", "waited")
+		published <- n
+	}()
+	receive(t, sub)
+	select {
+	case n := <-published:
+		if n != 1 {
+			t.Errorf("PUBLISH() after receive = %d, want 1", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PUBLISH() is blocked after the subscriber receives")
+	}
+
+	// Close stops the waiting publisher.
+	go func() {
+		n, _ := cli.PUBLISH(ctx, "user", "closed")
+		published <- n
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_ = sub.Close()
+	select {
+	case n := <-published:
+		if n != 0 {
+			t.Errorf("PUBLISH() to closed subscriber = %d, want 0", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PUBLISH() is blocked after Close()")
+	}
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"encoding"
+	"math"
+	"net"
+	"strconv"
+	"time"
+)
+
+// formatArg formats the value the same way go-redis writes the command argument,
+// so every engine stores the same string for the same value.
+// It returns false if go-redis can not write the value as is.
+func formatArg(val interface{}) (string, bool, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", true, nil
+	case string:
+		return v, true, nil
+	case []byte:
+		return string(v), true, nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), true, nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), true, nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), true, nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), true, nil
+	case int64:
+		return strconv.FormatInt(v, 10), true, nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), true, nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), true, nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), true, nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), true, nil
+	case uint64:
+		return strconv.FormatUint(v, 10), true, nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64), true, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true, nil
+	case bool:
+		if v {
+			return "1", true, nil
+		}
+		return "0", true, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true, nil
+	case time.Duration:
+		return strconv.FormatInt(v.Nanoseconds(), 10), true, nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", true, err
+		}
+		return string(b), true, nil
+	case net.IP:
+		return string(v), true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// formatFloat formats the score of the sorted set. The infinity is formatted as -inf and +inf.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aidapedia/gdk/log"
+	"go.uber.org/zap"
+)
+
+// Loader loads the value of the key on cache miss.
+type Loader[T any] func(ctx context.Context) (T, error)
+
+// loadEntry is the value stored by GetOrLoad. Delta and ExpireAt are used by the early expiration.
+type loadEntry[T any] struct {
+	Value T `json:"v"`
+	// Delta is the duration of the loader in milliseconds.
+	Delta int64 `json:"d"`
+	// ExpireAt is the unix milliseconds when the key expires, 0 means no expiration.
+	ExpireAt int64 `json:"e"`
+}
+
+// GetOrLoad returns the value of the key. On cache miss, it calls the loader and stores the value with ttl.
+//
+// The concurrent loads of the same key on this instance are deduplicated, and the key is reloaded
+// probabilistically before it expires, so the loader is not called by many callers at the same time.
+// The cache error is treated as a miss, and the value is returned even if it can not be stored,
+// in which case the store error is logged.
+// The key must be written only by GetOrLoad with the same T, as the value is wrapped with the load metadata.
+func GetOrLoad[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, loader Loader[T]) (T, error) {
+	var cached loadEntry[T]
+	err := c.GET(ctx, key).Scan(&cached)
+	if err == nil && !c.shouldReload(cached.Delta, cached.ExpireAt) {
+		return cached.Value, nil
+	}
+	hit := err == nil
+
+	val, err, _ := c.sl.Do(key, func() (interface{}, error) {
+		start := time.Now()
+		val, err := loader(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := loadEntry[T]{
+			Value: val,
+			Delta: time.Since(start).Milliseconds(),
+		}
+		exp := c.jitter(ttl)
+		if exp > 0 {
+			entry.ExpireAt = time.Now().Add(exp).UnixMilli()
+		}
+		if err := c.SET(ctx, key, entry, exp); err != nil {
+			log.WarnCtx(ctx, "Cache Store Error", zap.String("key", key), zap.Error(err))
+		}
+		return val, nil
+	})
+	if err != nil {
+		// The early reload fails while the cached value is still valid.
+		if hit {
+			return cached.Value, nil
+		}
+		var zero T
+		return zero, err
+	}
+	// The type assertion does not panic when T is an interface and the loader returns nil.
+	v, _ := val.(T)
+	return v, nil
+}
+
+// shouldReload decides the early expiration following the XFetch algorithm.
+func (c *Cache) shouldReload(delta, expireAt int64) bool {
+	if c.opt.EarlyExpirationBeta < 0 || expireAt == 0 {
+		return false
+	}
+	// -log(rand) is exponentially distributed, so the reload gets more likely as the key approaches its expiration.
+	gap := float64(delta) * c.opt.EarlyExpirationBeta * -math.Log(1-rand.Float64())
+	return float64(time.Now().UnixMilli())+gap >= float64(expireAt)
+}
+
+// jitter randomizes ttl by TTLJitter.
+func (c *Cache) jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 || c.opt.TTLJitter <= 0 {
+		return ttl
+	}
+	exp := ttl + time.Duration(float64(ttl)*c.opt.TTLJitter*(2*rand.Float64()-1))
+	if exp <= 0 {
+		// The key without expiration never reloads, so the TTL is kept instead.
+		return ttl
+	}
+	return exp
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+	"github.com/aidapedia/gdk/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestCache(t *testing.T, opt Options) *Cache {
+	t.Helper()
+	cli, err := engine.NewMemoryClient(engine.MemoryClientOpt{})
+	if err != nil {
+		t.Fatalf("NewMemoryClient() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+	return NewCacheWithOptions(cli, opt)
+}
+
+type user struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, Options{})
+
+	var calls int32
+	loader := func(ctx context.Context) (user, error) {
+		atomic.AddInt32(&calls, 1)
+		return user{ID: 1, Name: "gdk"}, nil
+	}
+	for i := 0; i < 3; i++ {
+		got, err := GetOrLoad(ctx, c, "user:1", time.Minute, loader)
+		if err != nil || got.Name != "gdk" {
+			t.Fatalf("GetOrLoad() = %+v, %v, want gdk", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader called %d times, want 1", calls)
+	}
+	if ttl, _ := c.TTL(ctx, "user:1"); ttl < 54*time.Second || ttl > 66*time.Second {
+		t.Errorf("TTL() = %v, want within the jitter of 1 minute", ttl)
+	}
+}
+
+func TestGetOrLoad_Error(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, Options{})
+	errLoad := errors.New("load failed")
+
+	_, err := GetOrLoad(ctx, c, "key", time.Minute, func(ctx context.Context) (string, error) {
+		return "", errLoad
+	})
+	if !errors.Is(err, errLoad) {
+		t.Fatalf("GetOrLoad() error = %v, want %v", err, errLoad)
+	}
+	if n, _ := c.EXISTS(ctx, "key"); n != 0 {
+		t.Errorf("EXISTS() after failed load = %d, want 0", n)
+	}
+}
+
+// failingSetEngine fails every SET.
+type failingSetEngine struct {
+	engine.Interface
+}
+
+func (failingSetEngine) SET(ctx context.Context, key string, val interface{}, exp time.Duration) error {
+	return errors.New("set failed")
+}
+
+func TestGetOrLoad_StoreError(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	prev := log.Log
+	log.Log = &log.Logger{Logger: zap.New(core)}
+	t.Cleanup(func() { log.Log = prev })
+
+	ctx := context.Background()
+	c := newTestCache(t, Options{})
+	c = NewCacheWithOptions(failingSetEngine{Interface: c.Interface}, Options{})
+
+	got, err := GetOrLoad(ctx, c, "key", time.Minute, func(ctx context.Context) (string, error) {
+		return "loaded", nil
+	})
+	if err != nil || got != "loaded" {
+		t.Fatalf("GetOrLoad() = %q, %v, want the loaded value", got, err)
+	}
+	if entries := logs.FilterMessage("Cache Store Error").All(); len(entries) != 1 {
+		t.Errorf("logged %d store errors, want 1", len(entries))
+	}
+}
+
+func TestGetOrLoad_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, Options{})
+
+	var (
+		calls int32
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = GetOrLoad(ctx, c, "key", time.Minute, func(ctx context.Context) (int, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(50 * time.Millisecond)
+				return 1, nil
+			})
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("loader called %d times, want 1", calls)
+	}
+}
+
+func TestCache_ShouldReload(t *testing.T) {
+	now := time.Now().UnixMilli()
+	tests := []struct {
+		name     string
+		beta     float64
+		delta    int64
+		expireAt int64
+		want     bool
+	}{
+		{name: "far from expiration", beta: 1, delta: 1, expireAt: now + time.Hour.Milliseconds(), want: false},
+		{name: "expired", beta: 1, delta: 0, expireAt: now - 1, want: true},
+		{name: "no expiration", beta: 1, delta: 1000, expireAt: 0, want: false},
+		{name: "disabled", beta: -1, delta: 0, expireAt: now - 1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache(t, Options{EarlyExpirationBeta: tt.beta})
+			if got := c.shouldReload(tt.delta, tt.expireAt); got != tt.want {
+				t.Errorf("shouldReload() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOrLoad_EarlyReloadError(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, Options{TTLJitter: -1})
+
+	// The stored entry is about to expire and takes long to load, so it is always reloaded early.
+	_ = c.SET(ctx, "key", loadEntry[string]{Value: "cached", Delta: time.Hour.Milliseconds(), ExpireAt: time.Now().Add(time.Minute).UnixMilli()}, time.Minute)
+	got, err := GetOrLoad(ctx, c, "key", time.Minute, func(ctx context.Context) (string, error) {
+		return "", errors.New("load failed")
+	})
+	if err != nil || got != "cached" {
+		t.Errorf("GetOrLoad() = %q, %v, want the cached value", got, err)
+	}
+}
+
+func TestCache_jitter(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttlJitter float64
+		ttl       time.Duration
+	}{
+		{name: "default", ttlJitter: 0, ttl: time.Minute},
+		{name: "jitter of 1 is lowered", ttlJitter: 1, ttl: time.Minute},
+		{name: "jitter above 1 is lowered", ttlJitter: 5, ttl: time.Minute},
+		{name: "tiny TTL", ttlJitter: 0.9, ttl: time.Nanosecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache(t, Options{TTLJitter: tt.ttlJitter})
+			for i := 0; i < 1000; i++ {
+				if got := c.jitter(tt.ttl); got <= 0 {
+					t.Fatalf("jitter(%v) = %v, want positive", tt.ttl, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,391 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotObtained is returned when the lock is held by another owner.
+	ErrNotObtained = errors.New("lock: not obtained")
+	// ErrNotHeld is returned when the lock is expired or taken over by another owner.
+	ErrNotHeld = errors.New("lock: not held")
+	// ErrNotSupported is returned by New and NewRedlock when the engine does not implement Backend.
+	ErrNotSupported = errors.New("lock: engine does not support lock")
+)
+
+// Backend is implemented by the engine that updates the key only if it holds the token, atomically.
+// GoRedisClient and MemoryClient implement it.
+type Backend interface {
+	// DELIFEQ deletes the key only if it holds val. It returns true if the key is deleted.
+	DELIFEQ(ctx context.Context, key string, val interface{}) (bool, error)
+	// EXPIREIFEQ sets the expiration only if the key holds val. It returns true if the expiration is set.
+	EXPIREIFEQ(ctx context.Context, key string, val interface{}, exp time.Duration) (bool, error)
+}
+
+// node is the cache node holding the lock.
+type node interface {
+	engine.Interface
+	Backend
+}
+
+// toNode returns ErrNotSupported when the engine does not implement Backend.
+func toNode(cli engine.Interface) (node, error) {
+	n, ok := cli.(node)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return n, nil
+}
+
+type Options struct {
+	// TTL is the lease of the lock. The lock is released automatically after the lease unless it is renewed.
+	// Default is 10 seconds.
+	TTL time.Duration
+	// AutoRenew renews the lease in the background until the lock is released.
+	// Default is false.
+	AutoRenew bool
+	// RenewInterval is the interval of the auto renewal.
+	// Default is a third of TTL.
+	RenewInterval time.Duration
+	// RetryInterval is the interval between the attempts of TryLock.
+	// Default is 50 milliseconds.
+	RetryInterval time.Duration
+	// KeyPrefix is prepended to the key of every lock.
+	// Default is "lock:".
+	KeyPrefix string
+	// ClockDrift is the fraction of TTL reserved for the clock drift between the nodes.
+	// Default is 0.01.
+	ClockDrift float64
+	// OnLost is called when the lock is lost before it is released, e.g. the auto renewal fails.
+	OnLost func(key string, err error)
+}
+
+func (o *Options) setDefault() {
+	if o.TTL <= 0 {
+		o.TTL = 10 * time.Second
+	}
+	if o.RenewInterval <= 0 {
+		o.RenewInterval = o.TTL / 3
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = 50 * time.Millisecond
+	}
+	if o.KeyPrefix == "" {
+		o.KeyPrefix = "lock:"
+	}
+	if o.ClockDrift <= 0 {
+		o.ClockDrift = 0.01
+	}
+}
+
+// Locker obtains the lock on one or many cache nodes.
+type Locker struct {
+	nodes  []node
+	quorum int
+	opt    Options
+}
+
+// New creates a new Locker on a single cache node. It returns ErrNotSupported when the engine does not implement Backend.
+func New(cli engine.Interface, opt Options) (*Locker, error) {
+	n, err := toNode(cli)
+	if err != nil {
+		return nil, err
+	}
+	opt.setDefault()
+	return &Locker{
+		nodes:  []node{n},
+		quorum: 1,
+		opt:    opt,
+	}, nil
+}
+
+// NewRedlock creates a new Locker following the Redlock algorithm.
+// The lock is obtained when the majority of the independent nodes accept it.
+// It returns ErrNotSupported when any node does not implement Backend.
+func NewRedlock(nodes []engine.Interface, opt Options) (*Locker, error) {
+	if len(nodes) == 0 {
+		return nil, errors.New("lock: no node")
+	}
+	backends := make([]node, 0, len(nodes))
+	for _, cli := range nodes {
+		n, err := toNode(cli)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, n)
+	}
+	opt.setDefault()
+	return &Locker{
+		nodes:  backends,
+		quorum: len(nodes)/2 + 1,
+		opt:    opt,
+	}, nil
+}
+
+// Obtain tries to obtain the lock once. It returns ErrNotObtained if the lock is held by another owner.
+//
+// The lock is released when ctx is done, so pass the context that lives as long as the lock is needed.
+func (l *Locker) Obtain(ctx context.Context, key string) (*Lock, error) {
+	token := uuid.NewString()
+	validUntil, err := l.acquire(ctx, l.opt.KeyPrefix+key, token)
+	if err != nil {
+		return nil, err
+	}
+	return newLock(ctx, l, key, token, validUntil), nil
+}
+
+// TryLock tries to obtain the lock until timeout. It returns ErrNotObtained if the lock is still held after timeout.
+//
+// The lock is released when ctx is done, so pass the context that lives as long as the lock is needed.
+func (l *Locker) TryLock(ctx context.Context, key string, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		lock, err := l.Obtain(ctx, key)
+		if !errors.Is(err, ErrNotObtained) {
+			return lock, err
+		}
+
+		wait := l.opt.RetryInterval
+		if remaining := time.Until(deadline); remaining < wait {
+			if remaining <= 0 {
+				return nil, ErrNotObtained
+			}
+			wait = remaining
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// acquire sets the token on every node and returns the end of the validity when the quorum is reached.
+func (l *Locker) acquire(ctx context.Context, key, token string) (time.Time, error) {
+	start := time.Now()
+	var (
+		obtained int
+		lastErr  error
+	)
+	for _, node := range l.nodes {
+		ok, err := node.SETNX(ctx, key, token, l.opt.TTL)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if ok {
+			obtained++
+		}
+	}
+
+	validUntil := start.Add(l.opt.TTL - l.drift())
+	if obtained >= l.quorum && time.Now().Before(validUntil) {
+		return validUntil, nil
+	}
+	// Release the minority so the other owner can obtain it without waiting for the lease.
+	l.release(context.WithoutCancel(ctx), key, token)
+	if obtained == 0 && lastErr != nil {
+		return time.Time{}, lastErr
+	}
+	return time.Time{}, ErrNotObtained
+}
+
+// extend renews the lease on every node holding the token.
+func (l *Locker) extend(ctx context.Context, key, token string, ttl time.Duration) (time.Time, error) {
+	start := time.Now()
+	var (
+		extended, failed int
+		lastErr          error
+	)
+	for _, node := range l.nodes {
+		ok, err := node.EXPIREIFEQ(ctx, key, token, ttl)
+		if err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		if ok {
+			extended++
+		}
+	}
+
+	if extended >= l.quorum {
+		return start.Add(ttl - l.drift()), nil
+	}
+	// The failed nodes may still hold the token, so the lock is not known to be lost.
+	if failed > 0 && extended+failed >= l.quorum {
+		return time.Time{}, lastErr
+	}
+	return time.Time{}, ErrNotHeld
+}
+
+// release deletes the token on every node and returns the number of nodes that held it.
+func (l *Locker) release(ctx context.Context, key, token string) (int, error) {
+	var (
+		released int
+		lastErr  error
+	)
+	for _, node := range l.nodes {
+		ok, err := node.DELIFEQ(ctx, key, token)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if ok {
+			released++
+		}
+	}
+	return released, lastErr
+}
+
+func (l *Locker) drift() time.Duration {
+	// 2 milliseconds is added for the precision of the expiration on Redis.
+	return time.Duration(float64(l.opt.TTL)*l.opt.ClockDrift) + 2*time.Millisecond
+}
+
+// Lock is the obtained lock. It is safe for concurrent use.
+type Lock struct {
+	locker *Locker
+	key    string
+	token  string
+
+	mu         sync.Mutex
+	validUntil time.Time
+	released   bool
+	done       chan struct{}
+	stop       chan struct{}
+}
+
+func newLock(ctx context.Context, l *Locker, key, token string, validUntil time.Time) *Lock {
+	lock := &Lock{
+		locker:     l,
+		key:        key,
+		token:      token,
+		validUntil: validUntil,
+		done:       make(chan struct{}),
+		stop:       make(chan struct{}),
+	}
+	if l.opt.AutoRenew || ctx.Done() != nil {
+		go lock.watch(ctx)
+	}
+	return lock
+}
+
+// Key returns the key of the lock without KeyPrefix.
+func (lock *Lock) Key() string {
+	return lock.key
+}
+
+// Token returns the random token identifying the owner of the lock.
+func (lock *Lock) Token() string {
+	return lock.token
+}
+
+// ValidUntil returns the time until the lock is guaranteed to be held.
+func (lock *Lock) ValidUntil() time.Time {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	return lock.validUntil
+}
+
+// Done returns a channel that is closed when the lock is released or lost.
+func (lock *Lock) Done() <-chan struct{} {
+	return lock.done
+}
+
+// Refresh renews the lease with ttl. It returns ErrNotHeld if the lock is expired or taken over.
+func (lock *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	if lock.released {
+		return ErrNotHeld
+	}
+
+	validUntil, err := lock.locker.extend(ctx, lock.locker.opt.KeyPrefix+lock.key, lock.token, ttl)
+	if err != nil {
+		return err
+	}
+	lock.validUntil = validUntil
+	return nil
+}
+
+// Release releases the lock. Only the owner of the token can release it.
+// It returns ErrNotHeld if the lock is expired or taken over.
+func (lock *Lock) Release(ctx context.Context) error {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	if lock.released {
+		return ErrNotHeld
+	}
+	lock.finish()
+
+	released, err := lock.locker.release(ctx, lock.locker.opt.KeyPrefix+lock.key, lock.token)
+	if err != nil && released == 0 {
+		return err
+	}
+	if released == 0 || time.Now().After(lock.validUntil) {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// finish marks the lock as released. It must be called with mu held.
+func (lock *Lock) finish() {
+	lock.released = true
+	close(lock.stop)
+	close(lock.done)
+}
+
+// watch renews the lease and releases the lock when ctx is done.
+func (lock *Lock) watch(ctx context.Context) {
+	var tick <-chan time.Time
+	if lock.locker.opt.AutoRenew {
+		ticker := time.NewTicker(lock.locker.opt.RenewInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-lock.stop:
+			return
+		case <-ctx.Done():
+			_ = lock.Release(context.WithoutCancel(ctx))
+			return
+		case <-tick:
+			err := lock.Refresh(context.WithoutCancel(ctx), lock.locker.opt.TTL)
+			if err == nil {
+				continue
+			}
+			// The transient error is retried on the next tick while the lease is still valid.
+			if !errors.Is(err, ErrNotHeld) && time.Now().Before(lock.ValidUntil()) {
+				continue
+			}
+			lock.lost(err)
+			return
+		}
+	}
+}
+
+// lost marks the lock as released without deleting the key and reports it to OnLost.
+func (lock *Lock) lost(err error) {
+	lock.mu.Lock()
+	if lock.released {
+		lock.mu.Unlock()
+		return
+	}
+	lock.finish()
+	lock.mu.Unlock()
+
+	if lock.locker.opt.OnLost != nil {
+		lock.locker.opt.OnLost(lock.key, err)
+	}
+}
@@ -0,0 +1,199 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+)
+
+func newTestNode(t *testing.T) *engine.MemoryClient {
+	t.Helper()
+	cli, err := engine.NewMemoryClient(engine.MemoryClientOpt{})
+	if err != nil {
+		t.Fatalf("NewMemoryClient() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+	return cli
+}
+
+func TestLocker_Obtain(t *testing.T) {
+	ctx := context.Background()
+	locker, err := New(newTestNode(t), Options{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	lock, err := locker.Obtain(ctx, "job")
+	if err != nil {
+		t.Fatalf("Obtain() failed: %v", err)
+	}
+	if _, err := locker.Obtain(ctx, "job"); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("Obtain() of held lock error = %v, want %v", err, ErrNotObtained)
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release() failed: %v", err)
+	}
+	if err := lock.Release(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("second Release() error = %v, want %v", err, ErrNotHeld)
+	}
+	select {
+	case <-lock.Done():
+	default:
+		t.Error("Done() is not closed after Release()")
+	}
+	if _, err := locker.Obtain(ctx, "job"); err != nil {
+		t.Errorf("Obtain() after Release() failed: %v", err)
+	}
+}
+
+func TestLock_ReleaseWithOtherToken(t *testing.T) {
+	ctx := context.Background()
+	node := newTestNode(t)
+	locker, err := New(node, Options{TTL: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	lock, err := locker.Obtain(ctx, "job")
+	if err != nil {
+		t.Fatalf("Obtain() failed: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	other, err := locker.Obtain(ctx, "job")
+	if err != nil {
+		t.Fatalf("Obtain() after expiration failed: %v", err)
+	}
+
+	if err := lock.Refresh(ctx, time.Minute); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Refresh() of expired lock error = %v, want %v", err, ErrNotHeld)
+	}
+	if err := lock.Release(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Release() of expired lock error = %v, want %v", err, ErrNotHeld)
+	}
+	if got := node.GET(ctx, "lock:job").Value(); got != other.Token() {
+		t.Errorf("token = %q, want the token of the new owner %q", got, other.Token())
+	}
+}
+
+func TestLocker_TryLock(t *testing.T) {
+	ctx := context.Background()
+	locker, err := New(newTestNode(t), Options{TTL: time.Minute, RetryInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	lock, err := locker.Obtain(ctx, "job")
+	if err != nil {
+		t.Fatalf("Obtain() failed: %v", err)
+	}
+	if _, err := locker.TryLock(ctx, "job", 30*time.Millisecond); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("TryLock() error = %v, want %v", err, ErrNotObtained)
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { _ = lock.Release(ctx) })
+	if _, err := locker.TryLock(ctx, "job", time.Second); err != nil {
+		t.Errorf("TryLock() after Release() failed: %v", err)
+	}
+}
+
+func TestLock_AutoRenew(t *testing.T) {
+	ctx := context.Background()
+	node := newTestNode(t)
+	lost := make(chan error, 1)
+	locker, err := New(node, Options{
+		TTL:       50 * time.Millisecond,
+		AutoRenew: true,
+		OnLost:    func(key string, err error) { lost <- err },
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	lock, err := locker.Obtain(ctx, "job")
+	if err != nil {
+		t.Fatalf("Obtain() failed: %v", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if _, err := locker.Obtain(ctx, "job"); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("Obtain() of renewed lock error = %v, want %v", err, ErrNotObtained)
+	}
+
+	// Taking over the key makes the next renewal fail.
+	_ = node.SET(ctx, "lock:job", "other", time.Minute)
+	select {
+	case err := <-lost:
+		if !errors.Is(err, ErrNotHeld) {
+			t.Errorf("OnLost() error = %v, want %v", err, ErrNotHeld)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnLost() is not called")
+	}
+	<-lock.Done()
+}
+
+func TestLock_ContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	locker, err := New(newTestNode(t), Options{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	lock, err := locker.Obtain(ctx, "job")
+	if err != nil {
+		t.Fatalf("Obtain() failed: %v", err)
+	}
+	cancel()
+	select {
+	case <-lock.Done():
+	case <-time.After(time.Second):
+		t.Fatal("lock is not released after the context is canceled")
+	}
+	if _, err := locker.Obtain(context.Background(), "job"); err != nil {
+		t.Errorf("Obtain() after cancel failed: %v", err)
+	}
+}
+
+func TestRedlock(t *testing.T) {
+	ctx := context.Background()
+	nodes := []engine.Interface{newTestNode(t), newTestNode(t), newTestNode(t)}
+	locker, err := NewRedlock(nodes, Options{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewRedlock() failed: %v", err)
+	}
+
+	// The minority held by another owner does not block the quorum.
+	_ = nodes[0].SET(ctx, "lock:job", "other", time.Minute)
+	lock, err := locker.Obtain(ctx, "job")
+	if err != nil {
+		t.Fatalf("Obtain() with quorum failed: %v", err)
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release() failed: %v", err)
+	}
+
+	// The majority held by another owner blocks it, and the minority is rolled back.
+	_ = nodes[1].SET(ctx, "lock:job", "other", time.Minute)
+	if _, err := locker.Obtain(ctx, "job"); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("Obtain() without quorum error = %v, want %v", err, ErrNotObtained)
+	}
+	if n, _ := nodes[2].EXISTS(ctx, "lock:job"); n != 0 {
+		t.Errorf("EXISTS() on the minority node = %d, want 0", n)
+	}
+}
+
+func TestNew_NotSupported(t *testing.T) {
+	var _ Backend = (*engine.GoRedisClient)(nil)
+	var _ Backend = (*engine.MemoryClient)(nil)
+
+	// The wrapper only has the methods of engine.Interface.
+	wrapped := struct{ engine.Interface }{newTestNode(t)}
+	if _, err := New(wrapped, Options{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("New() error = %v, want %v", err, ErrNotSupported)
+	}
+	if _, err := NewRedlock([]engine.Interface{newTestNode(t), wrapped}, Options{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("NewRedlock() error = %v, want %v", err, ErrNotSupported)
+	}
+}
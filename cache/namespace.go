@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/aidapedia/gdk/cache/engine"
+)
+
+// Namespace groups the keys under a generation, so the whole namespace is invalidated
+// in O(1) by bumping the generation. The keys of the old generation are left to expire.
+type Namespace struct {
+	cache *Cache
+	name  string
+}
+
+// Namespace returns the namespace with the name.
+func (c *Cache) Namespace(name string) *Namespace {
+	return &Namespace{
+		cache: c,
+		name:  name,
+	}
+}
+
+// Key returns the key on the current generation of the namespace, e.g. "user:3:123".
+// Use the returned key for every operation on the cache.
+func (ns *Namespace) Key(ctx context.Context, key string) (string, error) {
+	gen, err := ns.generation(ctx)
+	if err != nil {
+		return "", err
+	}
+	return ns.name + ":" + strconv.FormatInt(gen, 10) + ":" + key, nil
+}
+
+// Invalidate bumps the generation, so every key returned by Key before is not used anymore.
+func (ns *Namespace) Invalidate(ctx context.Context) error {
+	_, err := ns.cache.INCR(ctx, ns.cache.opt.NamespacePrefix+ns.name, 0)
+	return err
+}
+
+func (ns *Namespace) generation(ctx context.Context) (int64, error) {
+	var gen int64
+	err := ns.cache.GET(ctx, ns.cache.opt.NamespacePrefix+ns.name).Scan(&gen)
+	if errors.Is(err, engine.ErrNil) {
+		return 0, nil
+	}
+	return gen, err
+}
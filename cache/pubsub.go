@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+	"github.com/aidapedia/gdk/log"
+	"go.uber.org/zap"
+)
+
+// Handler handles the message decoded into T. The returned error is logged.
+type Handler[T any] func(ctx context.Context, channel string, msg T) error
+
+// KeyEventHandler handles the keyspace event of the key. The returned error is logged.
+type KeyEventHandler func(ctx context.Context, event, key string) error
+
+// Subscriber calls the handler for every received message until it is closed.
+type Subscriber struct {
+	subscribe func(ctx context.Context) (engine.Subscription, error)
+	handle    func(ctx context.Context, msg *engine.Message) error
+	interval  time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+	once      sync.Once
+}
+
+// Subscribe calls the handler with the message published to the channels, decoded into T.
+// The message that can not be decoded is logged and skipped.
+//
+// It returns after the first subscription succeeds. The lost subscription is subscribed again every
+// Options.ResubscribeInterval until the context is done or the subscriber is closed.
+func Subscribe[T any](ctx context.Context, c *Cache, handler Handler[T], channels ...string) (*Subscriber, error) {
+	subscribe := func(ctx context.Context) (engine.Subscription, error) {
+		return c.SUBSCRIBE(ctx, channels...)
+	}
+	return c.subscribe(ctx, subscribe, func(ctx context.Context, msg *engine.Message) error {
+		var val T
+		if err := msg.Scan(&val); err != nil {
+			return fmt.Errorf("decode message: %w", err)
+		}
+		return handler(ctx, msg.Channel, val)
+	})
+}
+
+// OnKeyEvent calls the handler with the keyspace events, every event when no event is given.
+// Redis must be configured with notify-keyspace-events. See engine.KeyEventPattern.
+func (c *Cache) OnKeyEvent(ctx context.Context, handler KeyEventHandler, events ...string) (*Subscriber, error) {
+	if len(events) == 0 {
+		events = []string{"*"}
+	}
+	patterns := make([]string, 0, len(events))
+	for _, event := range events {
+		patterns = append(patterns, engine.KeyEventPattern(event))
+	}
+	subscribe := func(ctx context.Context) (engine.Subscription, error) {
+		return c.PSUBSCRIBE(ctx, patterns...)
+	}
+	return c.subscribe(ctx, subscribe, func(ctx context.Context, msg *engine.Message) error {
+		event, key, ok := msg.KeyEvent()
+		if !ok {
+			return nil
+		}
+		return handler(ctx, event, key)
+	})
+}
+
+func (c *Cache) subscribe(ctx context.Context, subscribe func(ctx context.Context) (engine.Subscription, error), handle func(ctx context.Context, msg *engine.Message) error) (*Subscriber, error) {
+	sub, err := subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &Subscriber{
+		subscribe: subscribe,
+		handle:    handle,
+		interval:  c.opt.ResubscribeInterval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.run(ctx, sub)
+	return s, nil
+}
+
+func (s *Subscriber) run(ctx context.Context, sub engine.Subscription) {
+	defer close(s.done)
+	for {
+		select {
+		case <-ctx.Done():
+			_ = sub.Close()
+			return
+		case <-s.stop:
+			_ = sub.Close()
+			return
+		case msg, ok := <-sub.Channel():
+			if !ok {
+				if sub = s.resubscribe(ctx); sub == nil {
+					return
+				}
+				continue
+			}
+			s.call(ctx, msg)
+		}
+	}
+}
+
+// resubscribe subscribes until it succeeds. It returns nil when the context is done or the subscriber is closed.
+func (s *Subscriber) resubscribe(ctx context.Context) engine.Subscription {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.stop:
+			return nil
+		case <-timer.C:
+		}
+		sub, err := s.subscribe(ctx)
+		if err == nil {
+			return sub
+		}
+		log.WarnCtx(ctx, "Resubscribe Error", zap.Error(err))
+		timer.Reset(s.interval)
+	}
+}
+
+// call calls the handler, and logs its error and panic, so the subscriber keeps receiving.
+func (s *Subscriber) call(ctx context.Context, msg *engine.Message) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.ErrorCtx(ctx, "Subscriber Panic", zap.String("channel", msg.Channel), zap.Any("panic", r))
+		}
+	}()
+	if err := s.handle(ctx, msg); err != nil {
+		log.ErrorCtx(ctx, "Subscriber Error", zap.String("channel", msg.Channel), zap.Error(err))
+	}
+}
+
+// Close unsubscribes and waits for the running handler to return.
+func (s *Subscriber) Close() error {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+	return nil
+}
+
+// Done returns the channel closed after the subscriber stops, by Close or the context.
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+)
+
+func TestSubscribe(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, Options{})
+
+	received := make(chan user, 1)
+	sub, err := Subscribe(ctx, c, func(ctx context.Context, channel string, msg user) error {
+		received <- msg
+		return nil
+	}, "user")
+	if err != nil {
+		t.Fatalf("Subscribe() failed: %v", err)
+	}
+	_, _ = c.PUBLISH(ctx, "user", user{ID: 1, Name: "gdk"})
+	select {
+	case got := <-received:
+		if got.ID != 1 || got.Name != "gdk" {
+			t.Errorf("handler message = %+v, want 1 gdk", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler is not called")
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if n, _ := c.PUBLISH(ctx, "user", user{ID: 2}); n != 0 {
+		t.Errorf("PUBLISH() after Close() = %d, want 0", n)
+	}
+}
+
+func TestCache_OnKeyEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cli, err := engine.NewMemoryClient(engine.MemoryClientOpt{KeyspaceEvents: true})
+	if err != nil {
+		t.Fatalf("NewMemoryClient() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+	c := NewCache(cli)
+
+	expired := make(chan string, 1)
+	sub, err := c.OnKeyEvent(ctx, func(ctx context.Context, event, key string) error {
+		expired <- key
+		return nil
+	}, engine.EventExpired)
+	if err != nil {
+		t.Fatalf("OnKeyEvent() failed: %v", err)
+	}
+	_ = c.SET(ctx, "session", "1", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	_ = c.GET(ctx, "session")
+	select {
+	case key := <-expired:
+		if key != "session" {
+			t.Errorf("expired key = %s, want session", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler is not called")
+	}
+
+	cancel()
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("subscriber is not stopped after the context is canceled")
+	}
+}
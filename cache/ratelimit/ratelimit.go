@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+	"github.com/google/uuid"
+)
+
+// ErrNotSupported is returned by New when the engine does not implement Backend.
+var ErrNotSupported = errors.New("ratelimit: engine does not support rate limit")
+
+// Backend is implemented by the engine that counts the hits atomically.
+// GoRedisClient and MemoryClient implement it.
+type Backend interface {
+	// SLIDINGWINDOW records n hits with the unique id when the key has less than limit hits in the last window.
+	SLIDINGWINDOW(ctx context.Context, key string, limit int64, window time.Duration, n int64, id string) (engine.RateLimitResult, error)
+	// GCRA allows n hits following the generic cell rate algorithm of rate hits per period with burst.
+	GCRA(ctx context.Context, key string, rate int64, period time.Duration, burst int64, n int64) (engine.RateLimitResult, error)
+}
+
+// Algorithm is the algorithm used to count the hits.
+type Algorithm int
+
+const (
+	// SlidingWindow allows Rate hits in any Period. It keeps every hit of the last Period.
+	SlidingWindow Algorithm = iota
+	// GCRA spaces the hits evenly by Period / Rate, allowing Burst hits at once.
+	// It keeps a single value per key.
+	GCRA
+)
+
+// Limit is the number of the hits allowed per period.
+type Limit struct {
+	Rate   int64
+	Period time.Duration
+	// Burst is the number of the hits allowed at once by GCRA.
+	// Default is Rate.
+	Burst int64
+}
+
+// PerSecond returns the limit of rate hits per second.
+func PerSecond(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+
+// PerMinute returns the limit of rate hits per minute.
+func PerMinute(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+// PerHour returns the limit of rate hits per hour.
+func PerHour(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Hour}
+}
+
+func (l Limit) burst() int64 {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// Result is the result of a rate limit check.
+type Result = engine.RateLimitResult
+
+type Options struct {
+	// Algorithm is the algorithm used to count the hits.
+	// Default is SlidingWindow.
+	Algorithm Algorithm
+	// KeyPrefix is prepended to the key of every limit.
+	// Default is "ratelimit:".
+	KeyPrefix string
+}
+
+func (o *Options) setDefault() {
+	if o.KeyPrefix == "" {
+		o.KeyPrefix = "ratelimit:"
+	}
+}
+
+// Limiter limits the hits per key on the cache, so every instance sharing the cache shares the quota.
+type Limiter struct {
+	cli Backend
+	opt Options
+}
+
+// New creates a new Limiter. It returns ErrNotSupported when the engine does not implement Backend.
+func New(cli engine.Interface, opt Options) (*Limiter, error) {
+	backend, ok := cli.(Backend)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	opt.setDefault()
+	return &Limiter{
+		cli: backend,
+		opt: opt,
+	}, nil
+}
+
+// Allow reports whether a hit on the key is allowed by the limit.
+// Each key can use its own limit, e.g. a higher limit for a premium user.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+// AllowN reports whether n hits on the key are allowed by the limit. The hits are recorded only when allowed.
+func (l *Limiter) AllowN(ctx context.Context, key string, limit Limit, n int64) (Result, error) {
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		return Result{}, fmt.Errorf("ratelimit: invalid limit %d per %s", limit.Rate, limit.Period)
+	}
+	key = l.opt.KeyPrefix + key
+	switch l.opt.Algorithm {
+	case GCRA:
+		return l.cli.GCRA(ctx, key, limit.Rate, limit.Period, limit.burst(), n)
+	default:
+		return l.cli.SLIDINGWINDOW(ctx, key, limit.Rate, limit.Period, n, uuid.NewString())
+	}
+}
+
+// Wait blocks until a hit on the key is allowed by the limit or ctx is done.
+func (l *Limiter) Wait(ctx context.Context, key string, limit Limit) error {
+	for {
+		res, err := l.Allow(ctx, key, limit)
+		if err != nil {
+			return err
+		}
+		if res.Allowed {
+			return nil
+		}
+
+		timer := time.NewTimer(res.RetryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+)
+
+func newTestLimiter(t *testing.T, algorithm Algorithm) *Limiter {
+	t.Helper()
+	cli, err := engine.NewMemoryClient(engine.MemoryClientOpt{})
+	if err != nil {
+		t.Fatalf("NewMemoryClient() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+	limiter, err := New(cli, Options{Algorithm: algorithm})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return limiter
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+	}{
+		{name: "sliding window", algorithm: SlidingWindow},
+		{name: "GCRA", algorithm: GCRA},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newTestLimiter(t, tt.algorithm)
+			limit := PerMinute(3)
+
+			for i := int64(0); i < 3; i++ {
+				res, err := limiter.Allow(ctx, "user:1", limit)
+				if err != nil || !res.Allowed {
+					t.Fatalf("Allow() #%d = %+v, %v, want allowed", i, res, err)
+				}
+				if res.Remaining != 2-i {
+					t.Errorf("Remaining #%d = %d, want %d", i, res.Remaining, 2-i)
+				}
+			}
+			res, err := limiter.Allow(ctx, "user:1", limit)
+			if err != nil || res.Allowed {
+				t.Fatalf("Allow() over the limit = %+v, %v, want denied", res, err)
+			}
+			if res.RetryAfter <= 0 || res.RetryAfter > time.Minute {
+				t.Errorf("RetryAfter = %v, want within 1 minute", res.RetryAfter)
+			}
+
+			// The limit is counted per key.
+			if res, _ := limiter.Allow(ctx, "user:2", limit); !res.Allowed {
+				t.Error("Allow() of other key is denied")
+			}
+			// The denied hits are not recorded, so a smaller limit on another key is independent.
+			if res, _ := limiter.AllowN(ctx, "user:3", limit, 4); res.Allowed {
+				t.Error("AllowN() over the limit is allowed")
+			}
+			if res, _ := limiter.AllowN(ctx, "user:3", limit, 3); !res.Allowed {
+				t.Error("AllowN() within the limit is denied")
+			}
+		})
+	}
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	ctx := context.Background()
+	limiter := newTestLimiter(t, GCRA)
+	limit := Limit{Rate: 1, Period: 50 * time.Millisecond, Burst: 1}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, "key", limit); err != nil {
+			t.Fatalf("Wait() failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 hits took %v, want at least 2 periods", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, "key", limit); err != context.DeadlineExceeded {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestLimiter_InvalidLimit(t *testing.T) {
+	limiter := newTestLimiter(t, SlidingWindow)
+	if _, err := limiter.Allow(context.Background(), "key", Limit{}); err == nil {
+		t.Error("Allow() with zero limit succeeded")
+	}
+}
+
+func TestNew_NotSupported(t *testing.T) {
+	var _ Backend = (*engine.GoRedisClient)(nil)
+	var _ Backend = (*engine.MemoryClient)(nil)
+
+	cli, err := engine.NewMemoryClient(engine.MemoryClientOpt{})
+	if err != nil {
+		t.Fatalf("NewMemoryClient() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+	// The wrapper only has the methods of engine.Interface.
+	wrapped := struct{ engine.Interface }{cli}
+	if _, err := New(wrapped, Options{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("New() error = %v, want %v", err, ErrNotSupported)
+	}
+}
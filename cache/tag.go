@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTagNotSupported is returned when the engine does not implement TagBackend.
+var ErrTagNotSupported = errors.New("cache: engine does not support tags")
+
+// TagBackend is implemented by the engine that sets and invalidates the tagged keys atomically.
+// GoRedisClient and MemoryClient implement it.
+type TagBackend interface {
+	// SETTAGGED sets the value and adds the key to the sorted set of every tag key, scored by its expiration.
+	SETTAGGED(ctx context.Context, key string, val interface{}, exp time.Duration, tagKeys ...string) error
+	// DELTAGGED deletes the keys added to the tag keys together with the tag keys.
+	DELTAGGED(ctx context.Context, tagKeys ...string) error
+}
+
+// SetWithTags sets the value like SET and associates the key with every tag,
+// so the key is deleted by InvalidateTags of any of the tags.
+// The expired keys are pruned from the tags at the same time.
+func (c *Cache) SetWithTags(ctx context.Context, key string, val interface{}, exp time.Duration, tags ...string) error {
+	backend, ok := c.Interface.(TagBackend)
+	if !ok {
+		return ErrTagNotSupported
+	}
+	return backend.SETTAGGED(ctx, key, val, exp, c.tagKeys(tags)...)
+}
+
+// InvalidateTags deletes every key associated with the tags, together with the tags.
+// It is atomic with SetWithTags on the single node and the memory engine. See GoRedisClient.DELTAGGED
+// for the cluster and ring client.
+func (c *Cache) InvalidateTags(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	backend, ok := c.Interface.(TagBackend)
+	if !ok {
+		return ErrTagNotSupported
+	}
+	return backend.DELTAGGED(ctx, c.tagKeys(tags)...)
+}
+
+func (c *Cache) tagKeys(tags []string) []string {
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, c.opt.TagPrefix+tag)
+	}
+	return keys
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache_InvalidateTags(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, Options{})
+
+	_ = c.SetWithTags(ctx, "user:1", "a", time.Minute, "tenant:1")
+	_ = c.SetWithTags(ctx, "user:2", "b", 0, "tenant:1", "tenant:2")
+	_ = c.SetWithTags(ctx, "user:3", "c", time.Minute, "tenant:2")
+	_ = c.SET(ctx, "user:4", "d", 0)
+
+	if err := c.InvalidateTags(ctx, "tenant:1"); err != nil {
+		t.Fatalf("InvalidateTags() failed: %v", err)
+	}
+	tests := []struct {
+		key  string
+		want int64
+	}{
+		{key: "user:1", want: 0},
+		{key: "user:2", want: 0},
+		{key: "user:3", want: 1},
+		{key: "user:4", want: 1},
+		{key: "tag:tenant:1", want: 0},
+	}
+	for _, tt := range tests {
+		if n, _ := c.EXISTS(ctx, tt.key); n != tt.want {
+			t.Errorf("EXISTS(%s) = %d, want %d", tt.key, n, tt.want)
+		}
+	}
+	if err := c.InvalidateTags(ctx, "missing"); err != nil {
+		t.Errorf("InvalidateTags() of missing tag failed: %v", err)
+	}
+}
+
+func TestCache_InvalidateTagsConcurrent(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, Options{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = c.SetWithTags(ctx, fmt.Sprintf("key:%d:%d", i, j), "v", 0, "tag")
+			}
+		}(i)
+	}
+	for i := 0; i < 50; i++ {
+		_ = c.InvalidateTags(ctx, "tag")
+	}
+	wg.Wait()
+
+	// Every key that survives the invalidation must still be tracked by the tag.
+	if err := c.InvalidateTags(ctx, "tag"); err != nil {
+		t.Fatalf("InvalidateTags() failed: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 100; j++ {
+			key := fmt.Sprintf("key:%d:%d", i, j)
+			if n, _ := c.EXISTS(ctx, key); n != 0 {
+				t.Fatalf("%s is not invalidated", key)
+			}
+		}
+	}
+}
+
+func TestCache_SetWithTagsPrunesExpired(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, Options{})
+
+	_ = c.SetWithTags(ctx, "short", "a", 10*time.Millisecond, "tag")
+	time.Sleep(20 * time.Millisecond)
+	_ = c.SetWithTags(ctx, "long", "b", time.Minute, "tag")
+
+	if n, _ := c.ZCARD(ctx, "tag:tag"); n != 1 {
+		t.Errorf("ZCARD() = %d, want the expired key pruned", n)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(t, Options{})
+	ns := c.Namespace("user")
+
+	key, err := ns.Key(ctx, "1")
+	if err != nil || key != "user:0:1" {
+		t.Fatalf("Key() = %q, %v, want user:0:1", key, err)
+	}
+	_ = c.SET(ctx, key, "a", time.Minute)
+
+	if err := ns.Invalidate(ctx); err != nil {
+		t.Fatalf("Invalidate() failed: %v", err)
+	}
+	key, _ = ns.Key(ctx, "1")
+	if key != "user:1:1" {
+		t.Errorf("Key() after Invalidate() = %q, want user:1:1", key)
+	}
+	if n, _ := c.EXISTS(ctx, key); n != 0 {
+		t.Errorf("EXISTS() on the new generation = %d, want 0", n)
+	}
+}
@@ -0,0 +1,219 @@
+package callwrapper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RejectReason is the reason why the call is rejected by the admission control.
+type RejectReason string
+
+const (
+	// RejectReasonBulkhead is the reason when the max in-flight call is reached.
+	RejectReasonBulkhead RejectReason = "bulkhead_full"
+	// RejectReasonQueueFull is the reason when the waiting queue is full.
+	RejectReasonQueueFull RejectReason = "queue_full"
+	// RejectReasonQueueTimeout is the reason when the call waits longer than MaxWait.
+	RejectReasonQueueTimeout RejectReason = "queue_timeout"
+	// RejectReasonRateLimit is the reason when the rate limit is exceeded.
+	RejectReasonRateLimit RejectReason = "rate_limited"
+)
+
+// RejectedError is the error returned when the call is rejected by the admission control.
+type RejectedError struct {
+	// Name is the name of the callwrapper.
+	Name   string
+	Reason RejectReason
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("callwrapper %s rejected: %s", e.Name, e.Reason)
+}
+
+// StatusCode returns the HTTP status code of the rejection.
+// It is 429 for rate limit and 503 for the others.
+func (e *RejectedError) StatusCode() int {
+	if e.Reason == RejectReasonRateLimit {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusServiceUnavailable
+}
+
+// BulkheadOptions is the configuration for the max in-flight call.
+type BulkheadOptions struct {
+	// MaxConcurrent is the maximum number of in-flight call. Default is 0 (disabled).
+	MaxConcurrent int
+
+	// MaxWait is the maximum time to wait for a free slot. Default is 0 (reject immediately).
+	MaxWait time.Duration
+
+	// MaxQueue is the maximum number of waiting call. Default is 0 (no limit).
+	MaxQueue int
+}
+
+// RateLimitOptions is the configuration for the token bucket rate limit.
+type RateLimitOptions struct {
+	// Rate is the number of call allowed per Per. Default is 0 (disabled).
+	Rate int
+
+	// Per is the period of the Rate. Default is 1 second.
+	Per time.Duration
+
+	// Burst is the size of the token bucket. Default is equal to Rate.
+	Burst int
+
+	// MaxWait is the maximum time to wait for a token. Default is 0 (reject immediately).
+	MaxWait time.Duration
+}
+
+// admission is the admission control of the callwrapper.
+type admission struct {
+	name string
+
+	bulkhead BulkheadOptions
+	sem      chan struct{}
+	waiting  *atomic.Int64
+
+	rateLimit RateLimitOptions
+	limiter   *rate.Limiter
+}
+
+// newAdmission returns nil when both bulkhead and rate limit are disabled.
+func newAdmission(name string, bulkhead BulkheadOptions, rateLimit RateLimitOptions) *admission {
+	if bulkhead.MaxConcurrent <= 0 && rateLimit.Rate <= 0 {
+		return nil
+	}
+	a := &admission{
+		name:      name,
+		bulkhead:  bulkhead,
+		rateLimit: rateLimit,
+	}
+	if bulkhead.MaxConcurrent > 0 {
+		a.sem = make(chan struct{}, bulkhead.MaxConcurrent)
+		a.waiting = new(atomic.Int64)
+	}
+	if rateLimit.Rate > 0 {
+		if rateLimit.Per <= 0 {
+			rateLimit.Per = time.Second
+		}
+		if rateLimit.Burst <= 0 {
+			rateLimit.Burst = rateLimit.Rate
+		}
+		a.rateLimit = rateLimit
+		a.limiter = rate.NewLimiter(rate.Limit(float64(rateLimit.Rate)/rateLimit.Per.Seconds()), rateLimit.Burst)
+	}
+	return a
+}
+
+// inherit takes over the bulkhead slots and the rate limit tokens of the previous admission
+// when their limits are unchanged, so the options update does not reset them for the in-flight calls.
+func (a *admission) inherit(prev *admission) {
+	if a == nil || prev == nil {
+		return
+	}
+	if a.sem != nil && a.bulkhead.MaxConcurrent == prev.bulkhead.MaxConcurrent {
+		a.sem = prev.sem
+		a.waiting = prev.waiting
+	}
+	if a.limiter != nil && a.rateLimit == prev.rateLimit {
+		a.limiter = prev.limiter
+	}
+}
+
+// admit waits for the bulkhead slot, then the rate limit.
+// The slot is taken first, so the call rejected by the bulkhead does not consume the token of the rate limit.
+// It returns the function to release the slot.
+func (a *admission) admit(ctx context.Context) (release func(), err error) {
+	if a == nil {
+		return func() {}, nil
+	}
+	release, err = a.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// wait waits for the token of the rate limit.
+func (a *admission) wait(ctx context.Context) error {
+	if a.limiter == nil {
+		return nil
+	}
+	if a.rateLimit.MaxWait <= 0 {
+		if !a.limiter.Allow() {
+			return a.reject(RejectReasonRateLimit)
+		}
+		return nil
+	}
+	r := a.limiter.Reserve()
+	delay := r.Delay()
+	if !r.OK() || delay > a.rateLimit.MaxWait {
+		r.Cancel()
+		return a.reject(RejectReasonRateLimit)
+	}
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// acquire acquires the slot of the bulkhead.
+func (a *admission) acquire(ctx context.Context) (release func(), err error) {
+	if a.sem == nil {
+		return func() {}, nil
+	}
+	sem := a.sem
+	release = func() {
+		<-sem
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if a.bulkhead.MaxWait <= 0 {
+		return nil, a.reject(RejectReasonBulkhead)
+	}
+
+	waiting := a.waiting.Add(1)
+	defer a.waiting.Add(-1)
+	if a.bulkhead.MaxQueue > 0 && waiting > int64(a.bulkhead.MaxQueue) {
+		return nil, a.reject(RejectReasonQueueFull)
+	}
+
+	timer := time.NewTimer(a.bulkhead.MaxWait)
+	defer timer.Stop()
+	select {
+	case sem <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, a.reject(RejectReasonQueueTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (a *admission) reject(reason RejectReason) error {
+	return &RejectedError{
+		Name:   a.name,
+		Reason: reason,
+	}
+}
@@ -0,0 +1,143 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestAdmission_Bulkhead(t *testing.T) {
+	a := newAdmission("TestAdmission_Bulkhead", BulkheadOptions{
+		MaxConcurrent: 1,
+		MaxWait:       10 * time.Millisecond,
+		MaxQueue:      1,
+	}, RateLimitOptions{})
+	ctx := context.Background()
+
+	release, err := a.admit(ctx)
+	if err != nil {
+		t.Fatalf("admit() failed: %v", err)
+	}
+	_, err = a.admit(ctx)
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Reason != RejectReasonQueueTimeout {
+		t.Fatalf("admit() error = %v, want %v", err, RejectReasonQueueTimeout)
+	}
+	if rejected.StatusCode() != http.StatusServiceUnavailable {
+		t.Errorf("StatusCode() = %d, want %d", rejected.StatusCode(), http.StatusServiceUnavailable)
+	}
+
+	release()
+	release, err = a.admit(ctx)
+	if err != nil {
+		t.Fatalf("admit() after release failed: %v", err)
+	}
+	release()
+}
+
+func TestAdmission_RateLimit(t *testing.T) {
+	a := newAdmission("TestAdmission_RateLimit", BulkheadOptions{}, RateLimitOptions{
+		Rate: 2,
+		Per:  time.Minute,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := a.admit(ctx); err != nil {
+			t.Fatalf("admit() failed: %v", err)
+		}
+	}
+	_, err := a.admit(ctx)
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Reason != RejectReasonRateLimit {
+		t.Fatalf("admit() error = %v, want %v", err, RejectReasonRateLimit)
+	}
+	if rejected.StatusCode() != http.StatusTooManyRequests {
+		t.Errorf("StatusCode() = %d, want %d", rejected.StatusCode(), http.StatusTooManyRequests)
+	}
+}
+
+func TestAdmission_BulkheadBeforeRateLimit(t *testing.T) {
+	a := newAdmission("TestAdmission_BulkheadBeforeRateLimit", BulkheadOptions{
+		MaxConcurrent: 1,
+	}, RateLimitOptions{
+		Rate: 2,
+		Per:  time.Minute,
+	})
+	ctx := context.Background()
+
+	release, err := a.admit(ctx)
+	if err != nil {
+		t.Fatalf("admit() failed: %v", err)
+	}
+	// The call rejected by the bulkhead must not consume the token.
+	var rejected *RejectedError
+	if _, err := a.admit(ctx); !errors.As(err, &rejected) || rejected.Reason != RejectReasonBulkhead {
+		t.Fatalf("admit() error = %v, want %v", err, RejectReasonBulkhead)
+	}
+	release()
+
+	release, err = a.admit(ctx)
+	if err != nil {
+		t.Fatalf("admit() after the bulkhead rejection failed: %v", err)
+	}
+	release()
+	// The call rejected by the rate limit must release the slot.
+	if _, err := a.admit(ctx); !errors.As(err, &rejected) || rejected.Reason != RejectReasonRateLimit {
+		t.Fatalf("admit() error = %v, want %v", err, RejectReasonRateLimit)
+	}
+	if len(a.sem) != 0 {
+		t.Errorf("bulkhead slots in use = %d, want 0", len(a.sem))
+	}
+}
+
+func TestAdmission_RateLimitShortPeriod(t *testing.T) {
+	// Per is shorter than Rate nanoseconds, so the integer interval between the tokens would be zero.
+	a := newAdmission("TestAdmission_RateLimitShortPeriod", BulkheadOptions{}, RateLimitOptions{
+		Rate: 1000,
+		Per:  100 * time.Nanosecond,
+	})
+	if got, want := a.limiter.Limit(), rate.Limit(1e10); got == rate.Inf || math.Abs(float64(got-want)) > 1 {
+		t.Errorf("Limit() = %v, want %v", got, want)
+	}
+}
+
+func TestAdmission_Inherit(t *testing.T) {
+	bulkhead := BulkheadOptions{MaxConcurrent: 1}
+	rateLimit := RateLimitOptions{Rate: 2, Per: time.Minute}
+	prev := newAdmission("TestAdmission_Inherit", bulkhead, rateLimit)
+	ctx := context.Background()
+	release, err := prev.admit(ctx)
+	if err != nil {
+		t.Fatalf("admit() failed: %v", err)
+	}
+
+	// The in-flight call keeps the slot and the token after the update with the same limits.
+	a := newAdmission("TestAdmission_Inherit", BulkheadOptions{MaxConcurrent: 1, MaxWait: time.Millisecond}, rateLimit)
+	a.inherit(prev)
+	var rejected *RejectedError
+	if _, err := a.admit(ctx); !errors.As(err, &rejected) || rejected.Reason != RejectReasonQueueTimeout {
+		t.Fatalf("admit() error = %v, want %v", err, RejectReasonQueueTimeout)
+	}
+	release()
+	release, err = a.admit(ctx)
+	if err != nil {
+		t.Fatalf("admit() after release failed: %v", err)
+	}
+	release()
+	if _, err := a.admit(ctx); !errors.As(err, &rejected) || rejected.Reason != RejectReasonRateLimit {
+		t.Fatalf("admit() error = %v, want %v", err, RejectReasonRateLimit)
+	}
+
+	// The changed limits start over.
+	changed := newAdmission("TestAdmission_Inherit", BulkheadOptions{MaxConcurrent: 2}, RateLimitOptions{Rate: 3, Per: time.Minute})
+	changed.inherit(a)
+	if changed.sem == a.sem || changed.limiter == a.limiter {
+		t.Error("inherit() kept the state of the changed limits")
+	}
+}
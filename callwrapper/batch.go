@@ -0,0 +1,298 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aidapedia/gdk/callwrapper/pkg/cache"
+	"github.com/aidapedia/gdk/concurrency"
+	"github.com/aidapedia/gdk/telemetry/tracer"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrKeyNotFound is the error returned when the key is missing from the batch result.
+var ErrKeyNotFound = errors.New("key not found in batch result")
+
+// BatchFunc loads the values of the given keys in one call.
+// The key that is not found can be omitted from the result.
+// The context is the context of the attempt, which is canceled when Retry.AttemptTimeout is reached.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// BatchOptions is the configuration for the batch loader.
+type BatchOptions struct {
+	// Wait is the time window to coalesce the missing keys into one batch call. Default is 2 milliseconds.
+	Wait time.Duration
+
+	// MaxBatchSize is the maximum number of keys of one batch call. Default is 100.
+	// The batch is dispatched immediately when it is full.
+	MaxBatchSize int
+}
+
+func (o *BatchOptions) setDefault() {
+	if o.Wait == 0 {
+		o.Wait = time.Millisecond * 2
+	}
+	if o.MaxBatchSize <= 0 {
+		o.MaxBatchSize = 100
+	}
+}
+
+// Batch is the batch loader on top of the callwrapper, also known as dataloader.
+// It serves what it can from the cache, and coalesces the missing keys of concurrent callers
+// within the Wait window into one BatchFunc call.
+//
+// The stale value is treated as missing, so it is loaded again on the next batch.
+// The key missing from the batch result is stored on the negative cache when ErrKeyNotFound is accepted by
+// Options.NegativeCacheFilter or listed on Options.NegativeCacheErrors.
+type Batch[K comparable, V any] struct {
+	cw    *CallWrapper
+	keyFn func(key K) map[string]interface{}
+	fn    BatchFunc[K, V]
+	opt   BatchOptions
+
+	mu      sync.Mutex
+	pending *batch[K, V]
+}
+
+// batch is the collected keys of one BatchFunc call.
+type batch[K comparable, V any] struct {
+	keys    []K
+	done    chan struct{}
+	results map[K]V
+	err     error
+}
+
+// NewBatch creates a new batch loader on the callwrapper with the given name.
+// keyFn converts the key into the callwrapper key fields.
+//
+// Example:
+//
+//	loader := callwrapper.NewBatch("GetUserByID", func(id int) map[string]interface{} {
+//		return map[string]interface{}{"id": id}
+//	}, repo.GetUserByIDs, callwrapper.BatchOptions{})
+//	users, err := loader.LoadMany(ctx, []int{1, 2, 3})
+func NewBatch[K comparable, V any](name string, keyFn func(key K) map[string]interface{}, fn BatchFunc[K, V], opt BatchOptions) *Batch[K, V] {
+	cw, ok := GetCallWrapper(name).(*CallWrapper)
+	if !ok {
+		cw, _ = newCallWrapper(name, Options{})
+	}
+	opt.setDefault()
+	return &Batch[K, V]{
+		cw:    cw,
+		keyFn: keyFn,
+		fn:    fn,
+		opt:   opt,
+	}
+}
+
+// Load loads the value of the key.
+// It returns ErrKeyNotFound if the key is not found.
+func (b *Batch[K, V]) Load(ctx context.Context, key K) (resp V, err error) {
+	results, err := b.LoadMany(ctx, []K{key})
+	if err != nil {
+		return resp, err
+	}
+	resp, ok := results[key]
+	if !ok {
+		return resp, ErrKeyNotFound
+	}
+	return resp, nil
+}
+
+// LoadMany loads the values of the keys.
+// The key that is not found is omitted from the result.
+// It returns the first error of the batch call with the values that have been loaded.
+func (b *Batch[K, V]) LoadMany(ctx context.Context, keys []K) (results map[K]V, err error) {
+	w := b.cw.load()
+	if w.opt.Tracing {
+		var span tracer.Span
+		span, ctx = tracer.StartSpanFromContext(ctx, "callwrapper."+w.name)
+		defer func() {
+			span.Finish(err)
+		}()
+	}
+	done := w.metrics.start(ctx)
+	defer func() {
+		done(err)
+	}()
+
+	results = make(map[K]V, len(keys))
+	loading := make(map[K]<-chan singleflight.Result)
+	for _, key := range keys {
+		if _, ok := loading[key]; ok {
+			continue
+		}
+		if _, ok := results[key]; ok {
+			continue
+		}
+		keyStr := w.generateKey(b.keyFn(key))
+		if w.opt.Cache {
+			val, found, errCache := b.getCache(ctx, w, keyStr)
+			if errCache == nil {
+				if found {
+					results[key] = val
+				}
+				continue
+			}
+		}
+		loading[key] = b.load(ctx, w, key, keyStr)
+	}
+
+	for key, ch := range loading {
+		select {
+		case res := <-ch:
+			if res.Err != nil {
+				if !errors.Is(res.Err, ErrKeyNotFound) && err == nil {
+					err = res.Err
+				}
+				continue
+			}
+			results[key] = res.Val.(V)
+		case <-ctx.Done():
+			return results, ctx.Err()
+		}
+	}
+	return results, err
+}
+
+// getCache gets the value of the key from cache.
+// found is false when the key is stored on negative cache.
+func (b *Batch[K, V]) getCache(ctx context.Context, w *wrapper, keyStr string) (val V, found bool, err error) {
+	ent, err := w.getCache(ctx, keyStr)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			w.metrics.cacheLookup(ctx, resultMiss)
+		} else {
+			w.metrics.cacheLookup(ctx, resultError)
+			w.opt.Hook.OnWarnLog(ctx, "failed to get cache", err)
+		}
+		return val, false, err
+	}
+	if ent.isStale() {
+		w.metrics.cacheLookup(ctx, resultMiss)
+		return val, false, errors.New("stale cache")
+	}
+	if ent.Err != "" {
+		w.metrics.cacheLookup(ctx, resultHit)
+		return val, false, nil
+	}
+	if err := w.opt.Codec.Unmarshal(ent.Data, &val); err != nil {
+		w.metrics.cacheLookup(ctx, resultError)
+		w.opt.Hook.OnWarnLog(ctx, "failed to decode cache", err)
+		return val, false, err
+	}
+	w.metrics.cacheLookup(ctx, resultHit)
+	return val, true, nil
+}
+
+// load enqueues the key to the pending batch.
+// The concurrent load of the same key share the result by singleflight.
+func (b *Batch[K, V]) load(ctx context.Context, w *wrapper, key K, keyStr string) <-chan singleflight.Result {
+	return w.sl.DoChan("batch:"+keyStr, func() (interface{}, error) {
+		bt := b.enqueue(ctx, key)
+		<-bt.done
+		if bt.err != nil {
+			return nil, bt.err
+		}
+		val, ok := bt.results[key]
+		if !ok {
+			return nil, ErrKeyNotFound
+		}
+		return val, nil
+	})
+}
+
+// enqueue adds the key to the pending batch, and dispatch it when it is full or the wait window ends.
+func (b *Batch[K, V]) enqueue(ctx context.Context, key K) *batch[K, V] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending == nil {
+		bt := &batch[K, V]{
+			done: make(chan struct{}),
+		}
+		b.pending = bt
+		ctxBatch := context.WithoutCancel(ctx)
+		time.AfterFunc(b.opt.Wait, func() {
+			b.mu.Lock()
+			if b.pending != bt {
+				// The batch has been dispatched because it is full.
+				b.mu.Unlock()
+				return
+			}
+			b.pending = nil
+			b.mu.Unlock()
+			b.dispatch(ctxBatch, bt)
+		})
+	}
+
+	bt := b.pending
+	bt.keys = append(bt.keys, key)
+	if len(bt.keys) >= b.opt.MaxBatchSize {
+		b.pending = nil
+		go b.dispatch(context.WithoutCancel(ctx), bt)
+	}
+	return bt
+}
+
+// dispatch calls the batch function and stores the result to cache.
+// The panic is passed to the recover hook of concurrency, and returned to the callers of the batch.
+func (b *Batch[K, V]) dispatch(ctx context.Context, bt *batch[K, V]) {
+	defer close(bt.done)
+	if err := concurrency.Run(ctx, func(ctx context.Context) {
+		b.call(ctx, bt)
+	}); err != nil {
+		bt.err = err
+	}
+}
+
+// call calls the batch function with the admission control and retry, and stores the result to cache.
+func (b *Batch[K, V]) call(ctx context.Context, bt *batch[K, V]) {
+	w := b.cw.load()
+	if w.opt.Tracing {
+		var span tracer.Span
+		span, ctx = tracer.StartSpanFromContext(ctx, "callwrapper."+w.name+".batch")
+		defer func() {
+			span.Finish(bt.err)
+		}()
+	}
+
+	var attempts int
+	hookParam := w.opt.Hook.BeforeHook(ctx)
+	defer func() {
+		if hookParam == nil {
+			hookParam = make(map[string]interface{})
+		}
+		hookParam[HookParamAttempts] = attempts
+		w.opt.Hook.AfterHook(ctx, hookParam)
+	}()
+
+	release, err := w.admission.admit(ctx)
+	if err != nil {
+		w.metrics.rejectedCall(ctx, err)
+		bt.err = err
+		return
+	}
+	defer release()
+
+	resp, attempts, err := w.retry(ctx, func(ctx context.Context) (interface{}, error) {
+		return b.fn(ctx, bt.keys)
+	})
+	if err != nil {
+		bt.err = err
+		return
+	}
+	bt.results, _ = resp.(map[K]V)
+	if !w.opt.Cache {
+		return
+	}
+	for _, key := range bt.keys {
+		keyStr := w.generateKey(b.keyFn(key))
+		if val, ok := bt.results[key]; ok {
+			w.setCache(ctx, keyStr, val)
+		} else if w.opt.NegativeCache && w.opt.NegativeCacheFilter(ErrKeyNotFound) {
+			w.setNegativeCache(ctx, keyStr, ErrKeyNotFound)
+		}
+	}
+}
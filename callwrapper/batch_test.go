@@ -0,0 +1,93 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/concurrency"
+)
+
+func TestBatch_Load(t *testing.T) {
+	err := register(t, "TestBatch_Load", Options{
+		Cache:       true,
+		CacheClient: &mockCache{data: map[string]string{}},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var called int32
+	loader := NewBatch("TestBatch_Load", func(id int) map[string]interface{} {
+		return map[string]interface{}{"id": id}
+	}, func(ctx context.Context, ids []int) (map[int]user, error) {
+		atomic.AddInt32(&called, 1)
+		users := make(map[int]user)
+		for _, id := range ids {
+			if id != 404 {
+				users[id] = user{ID: id}
+			}
+		}
+		return users, nil
+	}, BatchOptions{Wait: 10 * time.Millisecond})
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for _, id := range []int{1, 2, 3, 1} {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			got, err := loader.Load(ctx, id)
+			if err != nil || got.ID != id {
+				t.Errorf("Load(%d) = %v, %v", id, got, err)
+			}
+		}(id)
+	}
+	wg.Wait()
+	if got := atomic.LoadInt32(&called); got != 1 {
+		t.Fatalf("batch function called %d times, want 1", got)
+	}
+
+	users, err := loader.LoadMany(ctx, []int{1, 2, 404})
+	if err != nil {
+		t.Fatalf("LoadMany() failed: %v", err)
+	}
+	if len(users) != 2 || users[1].ID != 1 || users[2].ID != 2 {
+		t.Errorf("LoadMany() = %v, want user 1 and 2", users)
+	}
+	if got := atomic.LoadInt32(&called); got != 2 {
+		t.Errorf("batch function called %d times, want 2 (only key 404 is missing)", got)
+	}
+	if _, err := loader.Load(ctx, 404); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Load(404) error = %v, want %v", err, ErrKeyNotFound)
+	}
+}
+
+func TestBatch_Panic(t *testing.T) {
+	recovered := make(chan interface{}, 1)
+	concurrency.SetRecoverHook(func(ctx context.Context, err interface{}) { recovered <- err })
+	t.Cleanup(func() {
+		concurrency.SetRecoverHook(func(ctx context.Context, err interface{}) {})
+	})
+	if err := register(t, "TestBatch_Panic", Options{}); err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	loader := NewBatch("TestBatch_Panic", func(id int) map[string]interface{} {
+		return map[string]interface{}{"id": id}
+	}, func(ctx context.Context, ids []int) (map[int]user, error) {
+		panic("boom")
+	}, BatchOptions{})
+
+	if _, err := loader.Load(context.Background(), 1); err == nil || err.Error() != "boom" {
+		t.Errorf("Load() error = %v, want boom", err)
+	}
+	select {
+	case <-recovered:
+	default:
+		t.Error("recover hook of concurrency is not called")
+	}
+}
@@ -0,0 +1,264 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCircuitOpen is the error returned when the circuit breaker is open.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrTooManyRequests is the error returned when the circuit breaker is half-open
+	// and the number of requests exceeds HalfOpenMaxRequests.
+	ErrTooManyRequests = errors.New("circuit breaker too many requests")
+)
+
+// CircuitState is the state of the circuit breaker.
+type CircuitState int
+
+const (
+	// StateClosed is the state when the call is allowed.
+	StateClosed CircuitState = iota
+	// StateHalfOpen is the state when limited call is allowed to check the dependency.
+	StateHalfOpen
+	// StateOpen is the state when the call is rejected.
+	StateOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerOptions is the configuration for the circuit breaker.
+type CircuitBreakerOptions struct {
+	// Toggle for circuit breaker. Default is false.
+	Enable bool
+
+	// ConsecutiveFailures is the number of consecutive failures to open the circuit. Default is 5.
+	ConsecutiveFailures uint32
+
+	// ErrorRate is the failure ratio (0 to 1) to open the circuit. Default is 0 (disabled).
+	// It is only checked after MinRequests is reached on the interval.
+	ErrorRate float64
+
+	// MinRequests is the minimum number of requests before ErrorRate is checked. Default is 10.
+	MinRequests uint32
+
+	// Interval is the cyclic period of the closed state to clear the counts. Default is 60 seconds.
+	Interval time.Duration
+
+	// CoolDown is the duration of the open state before it becomes half-open. Default is 30 seconds.
+	CoolDown time.Duration
+
+	// HalfOpenMaxRequests is the number of requests allowed on half-open state.
+	// The circuit is closed when all of them succeed. Default is 1.
+	HalfOpenMaxRequests uint32
+
+	// IsFailure decides whether the error is counted as failure. Default is every error.
+	IsFailure func(err error) bool
+
+	// Fallback returns the last cached value when the circuit is open. Default is false.
+	// Cache must be enabled to use fallback.
+	Fallback bool
+
+	// FallbackExpiration is the expiration time for the last cached value. Default is 24 hours.
+	FallbackExpiration time.Duration
+}
+
+func (o *CircuitBreakerOptions) setDefault() {
+	if o.ConsecutiveFailures == 0 {
+		o.ConsecutiveFailures = 5
+	}
+	if o.MinRequests == 0 {
+		o.MinRequests = 10
+	}
+	if o.Interval == 0 {
+		o.Interval = time.Second * 60
+	}
+	if o.CoolDown == 0 {
+		o.CoolDown = time.Second * 30
+	}
+	if o.HalfOpenMaxRequests == 0 {
+		o.HalfOpenMaxRequests = 1
+	}
+	if o.IsFailure == nil {
+		o.IsFailure = func(err error) bool {
+			return true
+		}
+	}
+	if o.FallbackExpiration == 0 {
+		o.FallbackExpiration = time.Hour * 24
+	}
+}
+
+// isCircuitError returns true if the error is returned by the circuit breaker.
+func isCircuitError(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrTooManyRequests)
+}
+
+type counts struct {
+	requests             uint32
+	failures             uint32
+	consecutiveSuccesses uint32
+	consecutiveFailures  uint32
+}
+
+type transition struct {
+	from, to CircuitState
+}
+
+type circuitBreaker struct {
+	mu  sync.Mutex
+	opt CircuitBreakerOptions
+
+	state      CircuitState
+	generation uint64
+	counts     counts
+	expiry     time.Time
+
+	// transitions is the state changes that have not been reported to the hook.
+	transitions   []transition
+	onStateChange func(ctx context.Context, from, to CircuitState)
+}
+
+func newCircuitBreaker(opt CircuitBreakerOptions, onStateChange func(ctx context.Context, from, to CircuitState)) *circuitBreaker {
+	cb := &circuitBreaker{
+		opt:           opt,
+		onStateChange: onStateChange,
+	}
+	cb.newGeneration(time.Now())
+	return cb
+}
+
+// getState returns the current state of the circuit breaker.
+func (cb *circuitBreaker) getState(ctx context.Context) CircuitState {
+	cb.mu.Lock()
+	state, _ := cb.currentState(time.Now())
+	cb.mu.Unlock()
+	cb.report(ctx)
+	return state
+}
+
+// allow checks whether the call is allowed and returns the generation of the call.
+func (cb *circuitBreaker) allow(ctx context.Context) (uint64, error) {
+	cb.mu.Lock()
+	state, generation := cb.currentState(time.Now())
+	var err error
+	switch {
+	case state == StateOpen:
+		err = ErrCircuitOpen
+	case state == StateHalfOpen && cb.counts.requests >= cb.opt.HalfOpenMaxRequests:
+		err = ErrTooManyRequests
+	default:
+		cb.counts.requests++
+	}
+	cb.mu.Unlock()
+	cb.report(ctx)
+	return generation, err
+}
+
+// done records the result of the call.
+func (cb *circuitBreaker) done(ctx context.Context, before uint64, err error) {
+	cb.mu.Lock()
+	now := time.Now()
+	state, generation := cb.currentState(now)
+	if generation == before {
+		if err != nil && cb.opt.IsFailure(err) {
+			cb.onFailure(state, now)
+		} else {
+			cb.onSuccess(state, now)
+		}
+	}
+	cb.mu.Unlock()
+	cb.report(ctx)
+}
+
+func (cb *circuitBreaker) onSuccess(state CircuitState, now time.Time) {
+	cb.counts.consecutiveSuccesses++
+	cb.counts.consecutiveFailures = 0
+	if state == StateHalfOpen && cb.counts.consecutiveSuccesses >= cb.opt.HalfOpenMaxRequests {
+		cb.setState(StateClosed, now)
+	}
+}
+
+func (cb *circuitBreaker) onFailure(state CircuitState, now time.Time) {
+	cb.counts.failures++
+	cb.counts.consecutiveFailures++
+	cb.counts.consecutiveSuccesses = 0
+	switch state {
+	case StateClosed:
+		if cb.shouldTrip() {
+			cb.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		cb.setState(StateOpen, now)
+	}
+}
+
+func (cb *circuitBreaker) shouldTrip() bool {
+	if cb.counts.consecutiveFailures >= cb.opt.ConsecutiveFailures {
+		return true
+	}
+	if cb.opt.ErrorRate > 0 && cb.counts.requests >= cb.opt.MinRequests {
+		return float64(cb.counts.failures)/float64(cb.counts.requests) >= cb.opt.ErrorRate
+	}
+	return false
+}
+
+func (cb *circuitBreaker) currentState(now time.Time) (CircuitState, uint64) {
+	switch cb.state {
+	case StateClosed:
+		if !cb.expiry.IsZero() && cb.expiry.Before(now) {
+			cb.newGeneration(now)
+		}
+	case StateOpen:
+		if cb.expiry.Before(now) {
+			cb.setState(StateHalfOpen, now)
+		}
+	}
+	return cb.state, cb.generation
+}
+
+func (cb *circuitBreaker) setState(state CircuitState, now time.Time) {
+	if cb.state == state {
+		return
+	}
+	cb.transitions = append(cb.transitions, transition{from: cb.state, to: state})
+	cb.state = state
+	cb.newGeneration(now)
+}
+
+func (cb *circuitBreaker) newGeneration(now time.Time) {
+	cb.generation++
+	cb.counts = counts{}
+	switch cb.state {
+	case StateClosed:
+		cb.expiry = now.Add(cb.opt.Interval)
+	case StateOpen:
+		cb.expiry = now.Add(cb.opt.CoolDown)
+	default:
+		cb.expiry = time.Time{}
+	}
+}
+
+// report calls the state change hook outside of the lock.
+func (cb *circuitBreaker) report(ctx context.Context) {
+	cb.mu.Lock()
+	transitions := cb.transitions
+	cb.transitions = nil
+	cb.mu.Unlock()
+	for _, t := range transitions {
+		cb.onStateChange(ctx, t.from, t.to)
+	}
+}
@@ -0,0 +1,93 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var transitions []CircuitState
+	opt := CircuitBreakerOptions{
+		ConsecutiveFailures: 2,
+		CoolDown:            10 * time.Millisecond,
+	}
+	opt.setDefault()
+	cb := newCircuitBreaker(opt, func(ctx context.Context, from, to CircuitState) {
+		transitions = append(transitions, to)
+	})
+	ctx := context.Background()
+	errDependency := errors.New("dependency error")
+
+	for i := 0; i < 2; i++ {
+		generation, err := cb.allow(ctx)
+		if err != nil {
+			t.Fatalf("allow() failed: %v", err)
+		}
+		cb.done(ctx, generation, errDependency)
+	}
+	if _, err := cb.allow(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	generation, err := cb.allow(ctx)
+	if err != nil {
+		t.Fatalf("allow() on half-open failed: %v", err)
+	}
+	if _, err := cb.allow(ctx); !errors.Is(err, ErrTooManyRequests) {
+		t.Fatalf("allow() error = %v, want %v", err, ErrTooManyRequests)
+	}
+	cb.done(ctx, generation, nil)
+	if got := cb.getState(ctx); got != StateClosed {
+		t.Fatalf("getState() = %v, want %v", got, StateClosed)
+	}
+
+	want := []CircuitState{StateOpen, StateHalfOpen, StateClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
+
+func TestCallWrapper_CircuitBreakerFallback(t *testing.T) {
+	err := register(t, "TestCallWrapper_CircuitBreakerFallback", Options{
+		Cache:           true,
+		CacheClient:     &mockCache{data: map[string]string{}},
+		CacheExpiration: time.Millisecond,
+		CircuitBreaker: CircuitBreakerOptions{
+			Enable:              true,
+			ConsecutiveFailures: 1,
+			Fallback:            true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	cw := NewTyped[string]("TestCallWrapper_CircuitBreakerFallback")
+	key := map[string]interface{}{"id": 1}
+
+	_, _ = cw.Call(context.Background(), key, func() (string, error) {
+		return "cached", nil
+	})
+	// mockCache does not expire, so remove the fresh entry manually
+	delete(cw.cw.load().cache.(*mockCache).data, cw.cw.load().generateKey(key))
+	_, err = cw.Call(context.Background(), key, func() (string, error) {
+		return "", errors.New("dependency error")
+	})
+	if err == nil {
+		t.Fatal("Call() succeeded unexpectedly")
+	}
+	got, err := cw.Call(context.Background(), key, func() (string, error) {
+		t.Fatal("fn is called when circuit is open")
+		return "", nil
+	})
+	if err != nil || got != "cached" {
+		t.Errorf("Call() = %v, %v, want fallback value", got, err)
+	}
+}
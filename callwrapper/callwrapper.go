@@ -2,36 +2,78 @@ package callwrapper
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aidapedia/gdk/callwrapper/pkg/cache"
-	"github.com/go-redis/redis/v8"
+	"github.com/aidapedia/gdk/codec"
+	"github.com/aidapedia/gdk/concurrency"
+	"github.com/aidapedia/gdk/telemetry/tracer"
 
 	"go.uber.org/zap"
 	"golang.org/x/sync/singleflight"
 )
 
-// CallWrapperName is the name of the callwrapper.
-// This name will be used to generate the cache key.
-// So make sure the name is unique.
-var callWrappers = make(map[string]Interface)
+// callFunc is the function that will be called with the context of the attempt.
+type callFunc = func(ctx context.Context) (interface{}, error)
 
-// CallFunc is the function that will be called.
-type callFunc = func() (interface{}, error)
+// decodeFunc decodes the cached value into the response.
+type decodeFunc = func(data []byte) (interface{}, error)
 
 // Interface is the interface for the callwrapper.
 type Interface interface {
-	Call(ctx context.Context, key map[string]interface{}, fn callFunc) (resp interface{}, err error)
+	Call(ctx context.Context, key map[string]interface{}, fn func() (interface{}, error)) (resp interface{}, err error)
+	// CallContext is the same as Call, but the function is called with the context of the attempt,
+	// which is canceled when Retry.AttemptTimeout is reached.
+	CallContext(ctx context.Context, key map[string]interface{}, fn func(ctx context.Context) (interface{}, error)) (resp interface{}, err error)
+	// Invalidate deletes the cached value of the key.
+	Invalidate(ctx context.Context, key map[string]interface{}) error
+	// InvalidateByPrefix deletes every cached value of the callwrapper that starts with the prefix.
+	// Empty prefix deletes every cached value of the callwrapper.
+	InvalidateByPrefix(ctx context.Context, prefix string) error
 }
 
 // CallWrapper is the wrapper for the call function.
+// The options can be replaced at runtime by Registry.Update.
 type CallWrapper struct {
+	name string
+	sl   singleflight.Group
+
+	// revalidating is the set of key that is being refreshed in background.
+	revalidating sync.Map
+
+	// wrapper is the current configured wrapper.
+	wrapper atomic.Pointer[wrapper]
+
+	// valueType is the type of the last fresh response of Call and CallContext.
+	// It is used to decode the cached value when the codec can not decode into interface{}.
+	valueType atomic.Pointer[reflect.Type]
+}
+
+// wrapper is the configured callwrapper.
+// It is replaced as a whole when the options change, so the call always uses consistent options.
+type wrapper struct {
 	name  string
-	sl    singleflight.Group
+	sl    *singleflight.Group
 	cache cache.Client
 	opt   Options
+
+	// breaker is nil when the circuit breaker is disabled.
+	breaker *circuitBreaker
+
+	// metrics is nil when the metrics is disabled.
+	metrics *metrics
+
+	// admission is nil when the bulkhead and rate limit are disabled.
+	admission *admission
+
+	// revalidating is shared with the CallWrapper.
+	revalidating *sync.Map
 }
 
 // Options is the configuration for the callwrapper
@@ -46,15 +88,108 @@ type Options struct {
 	// Cache wll improve perfomance. But if you need realtime data response, set ths value to false
 	Cache bool
 
+	// CacheSoftExpiration is the duration until the cached value is considered stale. Default is 0 (disabled).
+	// Stale value is still returned while one background call refresh the cache.
+	// It must be less than CacheExpiration.
+	CacheSoftExpiration time.Duration
+
+	// NegativeCache toggles caching of the error result. Default is false.
+	// It helps to prevent thundering herd on missing key.
+	NegativeCache bool
+
+	// NegativeCacheExpiration is the expiration time for the cached error. Default is 30 seconds.
+	NegativeCacheExpiration time.Duration
+
+	// NegativeCacheFilter decides which error is cached. Default is the error matching NegativeCacheErrors,
+	// so nothing is cached when both are empty. For example, only cache the not found error.
+	// The context error, RejectedError and the circuit breaker error are never cached, as they belong to the caller
+	// or to the moment of the call, not to the key.
+	NegativeCacheFilter func(err error) bool
+
+	// NegativeCacheErrors is the list of the known errors, usually the sentinel errors, restored from the negative cache.
+	// Default is empty. The cached error is returned as CachedError, which unwraps to the first entry matching
+	// the original error by errors.Is, so errors.Is and errors.As keep working on the cache hit.
+	// The entry is found by its message, so the message must be unique in the list.
+	NegativeCacheErrors []error
+
 	// CacheClient is the client for the cache.
 	CacheClient cache.Client
 
+	// KeyPrefix is the namespace prefix of the cache key. Default is empty.
+	KeyPrefix string
+
+	// KeyVersion is the version of the cache key. Default is 0 (no version).
+	// Bump the version when the cached value structure changes.
+	KeyVersion int
+
+	// KeyMaxLength is the maximum length of the cache key. Default is 0 (no limit).
+	// The key fields are hashed with SHA-256 when the key is longer than this value.
+	KeyMaxLength int
+
+	// Codec is the serializer for the cached value. Default is JSON.
+	// Gob and Protobuf can not decode into interface{}, so the untyped Call decodes the cached value
+	// into the type of the last fresh response, and misses the cache until the callwrapper has seen one.
+	// The envelope around the value, e.g. the stale time and the cached error, is always encoded by MessagePack.
+	Codec codec.Codec
+
+	// CircuitBreaker is the configuration for the circuit breaker.
+	CircuitBreaker CircuitBreakerOptions
+
+	// Retry is the configuration for the retry policy.
+	Retry RetryOptions
+
+	// Bulkhead is the configuration for the max in-flight call.
+	Bulkhead BulkheadOptions
+
+	// RateLimit is the configuration for the token bucket rate limit.
+	RateLimit RateLimitOptions
+
+	// Metrics toggles the OpenTelemetry metrics of the callwrapper. Default is false.
+	// It records call count, latency, cache hit/miss, singleflight shared call and in-flight call.
+	Metrics bool
+
+	// Tracing toggles the span around each call, including LoadMany and the BatchFunc call of Batch. Default is false.
+	Tracing bool
+
 	// Hook is the configuration for the hook.
 	Hook Hook
 }
 
-// New creates a new callwrapper.
+// New creates a new callwrapper on the default registry.
 func New(name string, opt Options) error {
+	return defaultRegistry.Register(name, opt)
+}
+
+func newCallWrapper(name string, opt Options) (*CallWrapper, error) {
+	cw := &CallWrapper{
+		name: name,
+	}
+	if err := cw.setOptions(opt); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// setOptions builds the wrapper from the options and replace the current one.
+func (cw *CallWrapper) setOptions(opt Options) error {
+	w, err := newWrapper(cw.name, opt)
+	if err != nil {
+		return err
+	}
+	w.sl = &cw.sl
+	w.revalidating = &cw.revalidating
+	if prev := cw.load(); prev != nil {
+		w.admission.inherit(prev.admission)
+	}
+	cw.wrapper.Store(w)
+	return nil
+}
+
+func (cw *CallWrapper) load() *wrapper {
+	return cw.wrapper.Load()
+}
+
+func newWrapper(name string, opt Options) (*wrapper, error) {
 	if opt.Hook.BeforeHook == nil {
 		opt.Hook.BeforeHook = func(ctx context.Context) map[string]interface{} {
 			return nil
@@ -79,78 +214,443 @@ func New(name string, opt Options) error {
 		}
 	}
 
+	if opt.Hook.OnStateChange == nil {
+		opt.Hook.OnStateChange = func(ctx context.Context, from, to CircuitState) {
+		}
+	}
+
+	if opt.Codec == nil {
+		opt.Codec = codec.NewJSON()
+	}
+
 	// Cache default configuration.
 	if opt.Cache {
 		if opt.CacheClient == nil {
-			return fmt.Errorf("cache client is nil")
+			return nil, fmt.Errorf("cache client is nil")
 		}
 
 		if opt.CacheExpiration == 0 {
 			opt.CacheExpiration = time.Minute * 5
 		}
+
+		if opt.CacheSoftExpiration >= opt.CacheExpiration {
+			return nil, fmt.Errorf("cache soft expiration must be less than cache expiration")
+		}
+
+		if opt.NegativeCache {
+			if opt.NegativeCacheExpiration == 0 {
+				opt.NegativeCacheExpiration = time.Second * 30
+			}
+
+			if opt.NegativeCacheFilter == nil {
+				known := opt.NegativeCacheErrors
+				opt.NegativeCacheFilter = func(err error) bool {
+					for _, e := range known {
+						if errors.Is(err, e) {
+							return true
+						}
+					}
+					return false
+				}
+			}
+		}
 	}
 
-	cw := &CallWrapper{
-		name: name,
-		opt:  opt,
+	opt.Retry.setDefault()
+
+	cw := &wrapper{
+		name:      name,
+		cache:     opt.CacheClient,
+		opt:       opt,
+		admission: newAdmission(name, opt.Bulkhead, opt.RateLimit),
 	}
 
-	// Check if the callwrapper name already exists.
-	if _, ok := callWrappers[name]; ok {
-		return fmt.Errorf("callwrapper name %s already exists", name)
+	// Circuit breaker default configuration.
+	if opt.CircuitBreaker.Enable {
+		if opt.CircuitBreaker.Fallback && !opt.Cache {
+			return nil, fmt.Errorf("circuit breaker fallback requires cache")
+		}
+		cw.opt.CircuitBreaker.setDefault()
+		cw.breaker = newCircuitBreaker(cw.opt.CircuitBreaker, cw.opt.Hook.OnStateChange)
 	}
 
-	callWrappers[name] = cw
-	return nil
+	if opt.Metrics {
+		m, err := newMetrics(name)
+		if err != nil {
+			return nil, err
+		}
+		cw.metrics = m
+	}
+	return cw, nil
 }
 
-// GetCallWrapper returns the callwrapper by name.
+// GetCallWrapper returns the callwrapper by name from the default registry.
 // Be careful when using this function.
-// If the callwrapper is not found, it will return nil.
+// If the callwrapper is not found, it will return callwrapper without cache and singleflight.
+// Use Registry.Get to get an error for unknown name.
 func GetCallWrapper(name string) Interface {
-	cw, ok := callWrappers[name]
-	if !ok {
-		return &CallWrapper{
-			name: name,
-		}
+	cw, err := defaultRegistry.Get(name)
+	if err != nil {
+		cw, _ = newCallWrapper(name, Options{})
 	}
 	return cw
 }
 
+// Name returns the name of the callwrapper.
+func (cw *CallWrapper) Name() string {
+	return cw.name
+}
+
+// Options returns the current options of the callwrapper with the default value applied.
+func (cw *CallWrapper) Options() Options {
+	return cw.load().opt
+}
+
 // Call executes the call function.
-func (cw *CallWrapper) Call(ctx context.Context, key map[string]interface{}, fn callFunc) (resp interface{}, err error) {
-	keyStr := generateKey(cw.name, key)
+//
+// The cached value is decoded by the codec into interface{},
+// or into the type of the last fresh response when the codec can not decode into interface{}.
+// For struct response, use Typed to get the same type on cache hit and fresh call.
+//
+// The function does not take the context, so Retry.AttemptTimeout can not stop it.
+// Use CallContext to stop the attempt on timeout.
+func (cw *CallWrapper) Call(ctx context.Context, key map[string]interface{}, fn func() (interface{}, error)) (resp interface{}, err error) {
+	return cw.CallContext(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return fn()
+	})
+}
+
+// CallContext executes the call function with the context of the attempt.
+// The context is canceled when Retry.AttemptTimeout is reached, and the attempt ends when the function returns,
+// so the function must respect the context to be stopped on timeout.
+func (cw *CallWrapper) CallContext(ctx context.Context, key map[string]interface{}, fn callFunc) (resp interface{}, err error) {
+	w := cw.load()
+	return w.call(ctx, key, func(ctx context.Context) (interface{}, error) {
+		resp, err := fn(ctx)
+		if err == nil && resp != nil {
+			typ := reflect.TypeOf(resp)
+			cw.valueType.Store(&typ)
+		}
+		return resp, err
+	}, cw.decode(w.opt.Codec))
+}
+
+// decode returns the decoder of the cached value for the untyped call.
+func (cw *CallWrapper) decode(c codec.Codec) func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		var val interface{}
+		err := c.Unmarshal(data, &val)
+		if err == nil {
+			return val, nil
+		}
+		typ := cw.valueType.Load()
+		if typ == nil {
+			return nil, err
+		}
+		ptr := reflect.New(*typ)
+		if err := c.Unmarshal(data, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return ptr.Elem().Interface(), nil
+	}
+}
+
+// Invalidate deletes the cached value of the key.
+// The cache client must implement cache.Deleter.
+func (cw *CallWrapper) Invalidate(ctx context.Context, key map[string]interface{}) error {
+	return cw.load().invalidate(ctx, key)
+}
+
+// InvalidateByPrefix deletes every cached value of the callwrapper that starts with the prefix.
+// The prefix is matched against the encoded key fields, for example `id=1`.
+// Empty prefix deletes every cached value of the callwrapper.
+// The cache client must implement cache.PrefixDeleter.
+func (cw *CallWrapper) InvalidateByPrefix(ctx context.Context, prefix string) error {
+	return cw.load().invalidateByPrefix(ctx, prefix)
+}
+
+// CircuitState returns the current state of the circuit breaker.
+// It always returns StateClosed when the circuit breaker is disabled.
+func (cw *CallWrapper) CircuitState(ctx context.Context) CircuitState {
+	return cw.load().circuitState(ctx)
+}
+
+func (cw *wrapper) call(ctx context.Context, key map[string]interface{}, fn callFunc, decode decodeFunc) (resp interface{}, err error) {
+	if cw.opt.Tracing {
+		var span tracer.Span
+		span, ctx = tracer.StartSpanFromContext(ctx, "callwrapper."+cw.name)
+		defer func() {
+			span.Finish(err)
+		}()
+	}
+	done := cw.metrics.start(ctx)
+	defer func() {
+		done(err)
+	}()
+
+	keyStr := cw.generateKey(key)
 	if cw.opt.Cache {
-		resp, err = cw.cache.Get(ctx, keyStr)
-		if err != redis.Nil {
-			return resp, nil
+		ent, err := cw.getCache(ctx, keyStr)
+		if err == nil {
+			if ent.Err != "" {
+				cw.metrics.cacheLookup(ctx, resultHit)
+				return nil, cw.cachedError(ent)
+			}
+			resp, err := decode(ent.Data)
+			if err == nil {
+				cw.metrics.cacheLookup(ctx, resultHit)
+				if ent.isStale() {
+					cw.revalidate(ctx, keyStr, fn)
+				}
+				return resp, nil
+			}
+			cw.metrics.cacheLookup(ctx, resultError)
+			cw.opt.Hook.OnWarnLog(ctx, "failed to decode cache", err)
+		} else if errors.Is(err, cache.ErrCacheMiss) {
+			cw.metrics.cacheLookup(ctx, resultMiss)
+		} else {
+			cw.metrics.cacheLookup(ctx, resultError)
+			cw.opt.Hook.OnWarnLog(ctx, "failed to get cache", err)
+		}
+	}
+	resp, err = cw.execute(ctx, keyStr, fn)
+	if err != nil && cw.opt.CircuitBreaker.Fallback && isCircuitError(err) {
+		fallback, errFallback := cw.getFallback(ctx, keyStr, decode)
+		if errFallback == nil {
+			return fallback, nil
+		}
+		if !errors.Is(errFallback, cache.ErrCacheMiss) {
+			cw.opt.Hook.OnWarnLog(ctx, "failed to get fallback cache", errFallback)
 		}
 	}
+	return resp, err
+}
+
+func (cw *wrapper) invalidate(ctx context.Context, key map[string]interface{}) error {
+	if !cw.opt.Cache {
+		return nil
+	}
+	deleter, ok := cw.cache.(cache.Deleter)
+	if !ok {
+		return cache.ErrNotSupported
+	}
+	keyStr := cw.generateKey(key)
+	return deleter.Delete(ctx, keyStr, fallbackKey(keyStr))
+}
+
+func (cw *wrapper) invalidateByPrefix(ctx context.Context, prefix string) error {
+	if !cw.opt.Cache {
+		return nil
+	}
+	deleter, ok := cw.cache.(cache.PrefixDeleter)
+	if !ok {
+		return cache.ErrNotSupported
+	}
+	namespace := cw.namespace()
+	err := deleter.DeleteByPrefix(ctx, namespace+keySeparator+prefix)
+	if err != nil || prefix != "" {
+		return err
+	}
+	// The key without fields is equal to the namespace.
+	if d, ok := cw.cache.(cache.Deleter); ok {
+		return d.Delete(ctx, namespace)
+	}
+	return nil
+}
+
+func (cw *wrapper) circuitState(ctx context.Context) CircuitState {
+	if cw.breaker == nil {
+		return StateClosed
+	}
+	return cw.breaker.getState(ctx)
+}
 
+// execute calls the function and store the result to cache.
+func (cw *wrapper) execute(ctx context.Context, keyStr string, fn callFunc) (interface{}, error) {
+	var attempts int
 	hookParam := cw.opt.Hook.BeforeHook(ctx)
 	defer func() {
-		cw.opt.Hook.AfterHook(ctx, hookParam)
-		if cw.opt.Cache && err != nil {
-			errCache := cw.cache.Set(ctx, keyStr, resp, cw.opt.CacheExpiration)
-			if errCache != nil {
-				cw.opt.Hook.OnWarnLog(ctx, "failed to set cache", err)
-			}
+		if hookParam == nil {
+			hookParam = make(map[string]interface{})
 		}
+		hookParam[HookParamAttempts] = attempts
+		cw.opt.Hook.AfterHook(ctx, hookParam)
 	}()
 
+	exec := func() (interface{}, error) {
+		release, err := cw.admission.admit(ctx)
+		if err != nil {
+			cw.metrics.rejectedCall(ctx, err)
+			return result{}, err
+		}
+		defer release()
+
+		resp, attempts, err := cw.retry(ctx, fn)
+		res := result{resp: resp, attempts: attempts}
+		if !cw.opt.Cache {
+			return res, err
+		}
+		if err == nil {
+			cw.setCache(ctx, keyStr, resp)
+		} else if cw.opt.NegativeCache && isCacheableError(err) && cw.opt.NegativeCacheFilter(err) {
+			cw.setNegativeCache(ctx, keyStr, err)
+		}
+		return res, err
+	}
+
+	var (
+		val    interface{}
+		err    error
+		shared bool
+	)
 	if cw.opt.Singleflight {
-		resp, err, _ = cw.sl.Do(keyStr, func() (interface{}, error) {
-			return fn()
-		})
-		return resp, err
+		val, err, shared = cw.sl.Do(keyStr, exec)
+		if shared {
+			cw.metrics.sharedCall(ctx)
+		}
+	} else {
+		val, err = exec()
 	}
-	resp, err = fn()
+	res := val.(result)
+	attempts = res.attempts
+	return res.resp, err
+}
+
+// result is the result of the execution.
+type result struct {
+	resp     interface{}
+	attempts int
+}
+
+// run calls the function through the circuit breaker.
+func (cw *wrapper) run(ctx context.Context, fn callFunc) (interface{}, error) {
+	if cw.breaker == nil {
+		return cw.attempt(ctx, fn)
+	}
+	generation, err := cw.breaker.allow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cw.attempt(ctx, fn)
+	cw.breaker.done(ctx, generation, err)
 	return resp, err
 }
 
-func generateKey(name string, key map[string]interface{}) string {
-	for k, v := range key {
-		name += fmt.Sprintf(":%s:%s", k, v)
+// revalidate refreshes the stale cache in background.
+// Only one refresh is running for each key.
+func (cw *wrapper) revalidate(ctx context.Context, keyStr string, fn callFunc) {
+	if _, loaded := cw.revalidating.LoadOrStore(keyStr, struct{}{}); loaded {
+		return
+	}
+	concurrency.Call(ctx, func(ctx context.Context) {
+		defer cw.revalidating.Delete(keyStr)
+		_, err, _ := cw.sl.Do("revalidate:"+keyStr, func() (interface{}, error) {
+			return cw.execute(ctx, keyStr, fn)
+		})
+		if err != nil {
+			cw.opt.Hook.OnWarnLog(ctx, "failed to revalidate cache", err)
+		}
+	})
+}
+
+// getCache gets the entry from cache.
+func (cw *wrapper) getCache(ctx context.Context, key string) (ent entry, err error) {
+	raw, err := cw.cache.Get(ctx, key)
+	if err != nil {
+		return ent, err
+	}
+	var data []byte
+	switch val := raw.(type) {
+	case []byte:
+		data = val
+	case string:
+		data = []byte(val)
+	default:
+		return ent, fmt.Errorf("unsupported cached value type %T", raw)
+	}
+	err = ent.unmarshal(data)
+	return ent, err
+}
+
+// setCache encodes the value and store it to cache.
+func (cw *wrapper) setCache(ctx context.Context, key string, val interface{}) {
+	data, err := cw.opt.Codec.Marshal(val)
+	if err != nil {
+		cw.opt.Hook.OnWarnLog(ctx, "failed to encode cache", err)
+		return
+	}
+	ent := entry{
+		Data: data,
+	}
+	if cw.opt.CacheSoftExpiration > 0 {
+		ent.StaleAt = time.Now().Add(cw.opt.CacheSoftExpiration).UnixNano()
+	}
+	cw.storeCache(ctx, key, ent, cw.opt.CacheExpiration)
+	if cw.opt.CircuitBreaker.Fallback {
+		cw.storeCache(ctx, fallbackKey(key), entry{Data: data}, cw.opt.CircuitBreaker.FallbackExpiration)
+	}
+}
+
+// getFallback gets the last cached value that is stored for the circuit breaker fallback.
+func (cw *wrapper) getFallback(ctx context.Context, key string, decode decodeFunc) (interface{}, error) {
+	ent, err := cw.getCache(ctx, fallbackKey(key))
+	if err != nil {
+		return nil, err
+	}
+	return decode(ent.Data)
+}
+
+// setNegativeCache stores the error to cache with the message of the matching NegativeCacheErrors entry.
+func (cw *wrapper) setNegativeCache(ctx context.Context, key string, err error) {
+	ent := entry{
+		Err: err.Error(),
+	}
+	for _, known := range cw.opt.NegativeCacheErrors {
+		if errors.Is(err, known) {
+			ent.Cause = known.Error()
+			break
+		}
+	}
+	cw.storeCache(ctx, key, ent, cw.opt.NegativeCacheExpiration)
+}
+
+// isCacheableError returns false for the error that does not depend on the key:
+// the context error, the rejection of the admission control and the circuit breaker error.
+func isCacheableError(err error) bool {
+	var rejected *RejectedError
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+		!errors.As(err, &rejected) && !isCircuitError(err)
+}
+
+// cachedError returns the error of the negative cache entry.
+func (cw *wrapper) cachedError(ent entry) error {
+	cached := &CachedError{
+		Message: ent.Err,
+	}
+	if ent.Cause == "" {
+		return cached
+	}
+	for _, known := range cw.opt.NegativeCacheErrors {
+		if known.Error() == ent.Cause {
+			cached.Err = known
+			break
+		}
+	}
+	return cached
+}
+
+func (cw *wrapper) storeCache(ctx context.Context, key string, ent entry, exp time.Duration) {
+	data, err := ent.marshal()
+	if err != nil {
+		cw.opt.Hook.OnWarnLog(ctx, "failed to encode cache", err)
+		return
+	}
+	err = cw.cache.Set(ctx, key, data, exp)
+	if err != nil {
+		cw.opt.Hook.OnWarnLog(ctx, "failed to set cache", err)
 	}
-	return name
+}
+
+func fallbackKey(key string) string {
+	return key + ":fallback"
 }
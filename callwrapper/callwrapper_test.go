@@ -0,0 +1,236 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// register registers the callwrapper on the default registry and unregisters it when the test ends,
+// so the test can run many times in the same process.
+func register(t *testing.T, name string, opt Options) error {
+	t.Helper()
+	err := New(name, opt)
+	if err == nil {
+		t.Cleanup(func() { _ = DefaultRegistry().Unregister(name) })
+	}
+	return err
+}
+
+func TestCallWrapper_CallCodec(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec codec.Codec
+		resp  interface{}
+	}{
+		{name: "JSON", codec: codec.NewJSON(), resp: "aida"},
+		{name: "Gob", codec: codec.NewGob(), resp: user{ID: 1, Name: "aida"}},
+		{name: "Msgpack", codec: codec.NewMsgpack(), resp: "aida"},
+		{name: "Protobuf", codec: codec.NewProtobuf(), resp: wrapperspb.String("aida")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "TestCallWrapper_CallCodec" + tt.name
+			err := register(t, name, Options{
+				Cache:       true,
+				CacheClient: &mockCache{data: map[string]string{}},
+				Codec:       tt.codec,
+			})
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			var called int
+			fn := func() (interface{}, error) {
+				called++
+				return tt.resp, nil
+			}
+			for i := 0; i < 2; i++ {
+				got, err := GetCallWrapper(name).Call(context.Background(), nil, fn)
+				if err != nil {
+					t.Fatalf("Call() failed: %v", err)
+				}
+				if msg, ok := tt.resp.(proto.Message); ok {
+					if got, _ := got.(proto.Message); !proto.Equal(got, msg) {
+						t.Errorf("Call() = %v, want %v", got, msg)
+					}
+				} else if got != tt.resp {
+					t.Errorf("Call() = %v, want %v", got, tt.resp)
+				}
+			}
+			if called != 1 {
+				t.Errorf("fn called %d times, want 1", called)
+			}
+		})
+	}
+}
+
+func TestCallWrapper_NegativeCache(t *testing.T) {
+	err := register(t, "TestCallWrapper_NegativeCache", Options{
+		Cache:         true,
+		CacheClient:   &mockCache{data: map[string]string{}},
+		NegativeCache: true,
+		NegativeCacheFilter: func(err error) bool {
+			return err.Error() == "not found"
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var called int
+	fn := func() (interface{}, error) {
+		called++
+		return nil, errors.New("not found")
+	}
+	key := map[string]interface{}{"id": 1}
+
+	_, err = GetCallWrapper("TestCallWrapper_NegativeCache").Call(context.Background(), key, fn)
+	if err == nil || err.Error() != "not found" {
+		t.Fatalf("Call() error = %v, want not found", err)
+	}
+	_, err = GetCallWrapper("TestCallWrapper_NegativeCache").Call(context.Background(), key, fn)
+	var cachedErr *CachedError
+	if !errors.As(err, &cachedErr) || cachedErr.Message != "not found" {
+		t.Fatalf("Call() error = %v, want cached not found", err)
+	}
+	if called != 1 {
+		t.Errorf("fn called %d times, want 1", called)
+	}
+}
+
+func TestCallWrapper_NegativeCacheSkipsCallerError(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Options
+	}{
+		{name: "default filter", opt: Options{NegativeCacheErrors: []error{errors.New("not found")}}},
+		{name: "filter of every error", opt: Options{NegativeCacheFilter: func(err error) bool { return true }}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := fmt.Sprintf("TestCallWrapper_NegativeCacheSkipsCallerError%d", i)
+			tt.opt.Cache = true
+			tt.opt.CacheClient = &mockCache{data: map[string]string{}}
+			tt.opt.NegativeCache = true
+			if err := register(t, name, tt.opt); err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			var called int
+			fn := func(ctx context.Context) (interface{}, error) {
+				called++
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				return "ok", nil
+			}
+			key := map[string]interface{}{"id": 1}
+
+			canceled, cancel := context.WithCancel(context.Background())
+			cancel()
+			if _, err := GetCallWrapper(name).CallContext(canceled, key, fn); !errors.Is(err, context.Canceled) {
+				t.Fatalf("CallContext() error = %v, want %v", err, context.Canceled)
+			}
+			// The next caller with a live context must not get the error of the canceled one.
+			resp, err := GetCallWrapper(name).CallContext(context.Background(), key, fn)
+			if err != nil || resp != "ok" {
+				t.Fatalf("CallContext() = %v, %v, want ok", resp, err)
+			}
+			if called != 2 {
+				t.Errorf("fn called %d times, want 2", called)
+			}
+		})
+	}
+}
+
+func TestCallWrapper_NegativeCacheErrors(t *testing.T) {
+	errNotFound := errors.New("not found")
+	err := register(t, "TestCallWrapper_NegativeCacheErrors", Options{
+		Cache:               true,
+		CacheClient:         &mockCache{data: map[string]string{}},
+		NegativeCache:       true,
+		NegativeCacheErrors: []error{errNotFound},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCached bool
+	}{
+		{name: "known error", err: fmt.Errorf("user 1: %w", errNotFound), wantCached: true},
+		{name: "unknown error", err: errors.New("bad gateway")},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := map[string]interface{}{"id": i}
+			fn := func() (interface{}, error) {
+				return nil, tt.err
+			}
+			_, _ = GetCallWrapper("TestCallWrapper_NegativeCacheErrors").Call(context.Background(), key, fn)
+			_, err := GetCallWrapper("TestCallWrapper_NegativeCacheErrors").Call(context.Background(), key, fn)
+			var cachedErr *CachedError
+			if cached := errors.As(err, &cachedErr); cached != tt.wantCached {
+				t.Fatalf("Call() error = %v, cached = %v, want %v", err, cached, tt.wantCached)
+			}
+			if !tt.wantCached {
+				return
+			}
+			if cachedErr.Message != tt.err.Error() {
+				t.Errorf("Message = %q, want %q", cachedErr.Message, tt.err.Error())
+			}
+			if !errors.Is(err, errNotFound) || cachedErr.Unwrap() != errNotFound {
+				t.Errorf("cached error %v does not unwrap to %v", err, errNotFound)
+			}
+		})
+	}
+}
+
+func TestCallWrapper_StaleWhileRevalidate(t *testing.T) {
+	err := register(t, "TestCallWrapper_StaleWhileRevalidate", Options{
+		Cache:               true,
+		CacheClient:         &mockCache{data: map[string]string{}},
+		CacheExpiration:     time.Minute,
+		CacheSoftExpiration: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var called int32
+	fn := func() (int, error) {
+		return int(atomic.AddInt32(&called, 1)), nil
+	}
+	key := map[string]interface{}{"id": 1}
+	cw := NewTyped[int]("TestCallWrapper_StaleWhileRevalidate")
+
+	got, _ := cw.Call(context.Background(), key, fn)
+	if got != 1 {
+		t.Fatalf("Call() = %v, want 1", got)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// stale value is returned while refreshing in background
+	got, _ = cw.Call(context.Background(), key, fn)
+	if got != 1 {
+		t.Fatalf("Call() = %v, want stale value 1", got)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(&called) != 2 {
+		t.Fatalf("fn called %d times, want 2", atomic.LoadInt32(&called))
+	}
+	got, _ = cw.Call(context.Background(), key, fn)
+	if got != 2 {
+		t.Errorf("Call() = %v, want refreshed value 2", got)
+	}
+}
@@ -0,0 +1,53 @@
+package callwrapper
+
+import (
+	"time"
+
+	"github.com/aidapedia/gdk/codec"
+)
+
+// envelopeCodec encodes the entry itself. Options.Codec only encodes entry.Data, so the codec
+// that can only encode some types, e.g. Protobuf, still works with the envelope.
+var envelopeCodec = codec.NewMsgpack()
+
+// entry is the envelope of the cached value.
+type entry struct {
+	// Data is the value encoded by the codec.
+	Data []byte `json:"data,omitempty"`
+	// Err is the error message of the negative cache.
+	Err string `json:"err,omitempty"`
+	// Cause is the message of the Options.NegativeCacheErrors entry matching the cached error.
+	Cause string `json:"cause,omitempty"`
+	// StaleAt is the unix nano time when the entry is considered stale.
+	StaleAt int64 `json:"stale_at,omitempty"`
+}
+
+func (e entry) marshal() ([]byte, error) {
+	return envelopeCodec.Marshal(e)
+}
+
+func (e *entry) unmarshal(data []byte) error {
+	return envelopeCodec.Unmarshal(data, e)
+}
+
+func (e entry) isStale() bool {
+	return e.StaleAt > 0 && time.Now().UnixNano() > e.StaleAt
+}
+
+// CachedError is the error returned from the negative cache.
+// The original error can not be stored on the cache, so only its message is kept.
+// CachedError unwraps to the entry of Options.NegativeCacheErrors that matched the original error,
+// so errors.Is and errors.As work against it on the cache hit.
+type CachedError struct {
+	Message string
+	// Err is the entry of Options.NegativeCacheErrors matching the original error. It is nil when none matched.
+	Err error
+}
+
+func (e *CachedError) Error() string {
+	return e.Message
+}
+
+func (e *CachedError) Unwrap() error {
+	return e.Err
+}
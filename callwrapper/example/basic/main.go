@@ -70,20 +70,18 @@ func main() {
 	time.Sleep(3 * time.Second)
 
 	// with redis cache
+	// Typed callwrapper decode the cached value into User
 	for i := 0; i < 2; i++ {
-		var resp interface{}
-		var err error
 		go func() {
-			resp, err = callwrapper.GetCallWrapper("GetUserByIDwithRedis").Call(ctx, map[string]interface{}{
+			user, err := callwrapper.Call(ctx, "GetUserByIDwithRedis", map[string]interface{}{
 				"id": 1,
-			}, func() (interface{}, error) {
+			}, func() (User, error) {
 				fmt.Println("calling GetUserByIDwithRedis")
 				return repo.GetUserByID(ctx, 1)
 			})
 			if err != nil {
 				panic(err)
 			}
-			user := resp.(User)
 			fmt.Println("Redis User ID: ", user.ID)
 		}()
 	}
@@ -2,11 +2,17 @@ package callwrapper
 
 import "context"
 
+const (
+	// HookParamAttempts is the key of the number of attempts on the AfterHook param.
+	HookParamAttempts = "attempts"
+)
+
 type Hook struct {
 	// Hook that is called when success execution
 	BeforeHook func(ctx context.Context) map[string]interface{}
 
 	// Hook that is called when success execution
+	// The param is the value returned by BeforeHook with HookParamAttempts.
 	AfterHook func(ctx context.Context, param map[string]interface{})
 
 	// OnErrorLog is the hook that is called when error occurs
@@ -14,4 +20,7 @@ type Hook struct {
 
 	// OnWarnLog is the hook that is called when error occurs but still tolerable.
 	OnWarnLog func(ctx context.Context, msg string, err error)
+
+	// OnStateChange is the hook that is called when the circuit breaker state changes.
+	OnStateChange func(ctx context.Context, from, to CircuitState)
 }
@@ -0,0 +1,105 @@
+package callwrapper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// keySeparator separates the segment of the cache key.
+	keySeparator = ":"
+	// hashedFieldsPrefix marks the fields segment that has been hashed.
+	hashedFieldsPrefix = "h="
+)
+
+// keyEscaper escapes the character that is used as separator.
+var keyEscaper = strings.NewReplacer(`\`, `\\`, ":", `\x3a`, "=", `\x3d`)
+
+// namespace returns the prefix of every cache key of the callwrapper.
+// Format: [KeyPrefix:]name[:vKeyVersion]
+func (cw *wrapper) namespace() string {
+	var sb strings.Builder
+	if cw.opt.KeyPrefix != "" {
+		sb.WriteString(cw.opt.KeyPrefix)
+		sb.WriteString(keySeparator)
+	}
+	sb.WriteString(cw.name)
+	if cw.opt.KeyVersion > 0 {
+		sb.WriteString(keySeparator)
+		sb.WriteString("v")
+		sb.WriteString(strconv.Itoa(cw.opt.KeyVersion))
+	}
+	return sb.String()
+}
+
+// generateKey generates the cache key from the namespace and the fields.
+// The fields are sorted, so the same key map always produces the same string.
+// The fields segment is hashed when it is longer than KeyMaxLength.
+func (cw *wrapper) generateKey(key map[string]interface{}) string {
+	namespace := cw.namespace()
+	fields := encodeFields(key)
+	if fields == "" {
+		return namespace
+	}
+	if cw.opt.KeyMaxLength > 0 && len(namespace)+len(keySeparator)+len(fields) > cw.opt.KeyMaxLength {
+		sum := sha256.Sum256([]byte(fields))
+		fields = hashedFieldsPrefix + hex.EncodeToString(sum[:])
+	}
+	return namespace + keySeparator + fields
+}
+
+// encodeFields encodes the fields as sorted field=value pairs.
+func encodeFields(key map[string]interface{}) string {
+	names := make([]string, 0, len(key))
+	for k := range key {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, k := range names {
+		pairs = append(pairs, keyEscaper.Replace(k)+"="+encodeValue(key[k]))
+	}
+	return strings.Join(pairs, keySeparator)
+}
+
+// encodeValue encodes the value with its type, so string "1" and int 1 produce different value.
+// String is quoted, number and boolean are formatted as is, and the other type is encoded as JSON.
+func encodeValue(val interface{}) string {
+	if val == nil {
+		return "null"
+	}
+	if t, ok := val.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+
+	var str string
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.String:
+		str = strconv.Quote(rv.String())
+	case reflect.Bool:
+		str = strconv.FormatBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		str = strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		str = strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		str = strconv.FormatFloat(rv.Float(), 'g', -1, 64)
+	default:
+		// encoding/json sorts the map keys, so the result is deterministic.
+		data, err := json.Marshal(val)
+		if err != nil {
+			str = strconv.Quote(rv.Type().String())
+		} else {
+			str = string(data)
+		}
+	}
+	return keyEscaper.Replace(str)
+}
@@ -0,0 +1,98 @@
+package callwrapper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCallWrapper_generateKey(t *testing.T) {
+	type userID int
+	tests := []struct {
+		name string
+		opt  Options
+		key  map[string]interface{}
+		want string
+	}{
+		{
+			name: "no fields",
+			key:  nil,
+			want: "GetUser",
+		},
+		{
+			name: "sorted fields",
+			key:  map[string]interface{}{"name": "aida", "id": 1, "active": true},
+			want: `GetUser:active=true:id=1:name="aida"`,
+		},
+		{
+			name: "typed value",
+			key:  map[string]interface{}{"id": "1", "user_id": userID(1)},
+			want: `GetUser:id="1":user_id=1`,
+		},
+		{
+			name: "escaped separator",
+			key:  map[string]interface{}{"a:b": "c:d", "ids": []int{1, 2}},
+			want: `GetUser:a\x3ab="c\x3ad":ids=[1,2]`,
+		},
+		{
+			name: "prefix and version",
+			opt:  Options{KeyPrefix: "svc", KeyVersion: 2},
+			key:  map[string]interface{}{"id": 1},
+			want: "svc:GetUser:v2:id=1",
+		},
+		{
+			name: "hashed fields",
+			opt:  Options{KeyMaxLength: 10},
+			key:  map[string]interface{}{"id": 1},
+			want: "GetUser:h=d9fc91d45c096b5e27dc6304d8015dc5cb0a959cd078ca25fafcf2333087728e",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw, err := newWrapper("GetUser", tt.opt)
+			if err != nil {
+				t.Fatalf("newWrapper() failed: %v", err)
+			}
+			got := cw.generateKey(tt.key)
+			if got != tt.want {
+				t.Errorf("generateKey() = %v, want %v", got, tt.want)
+			}
+			// Same key must produce the same string.
+			for i := 0; i < 10; i++ {
+				if again := cw.generateKey(tt.key); again != got {
+					t.Fatalf("generateKey() is not deterministic: %v != %v", again, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCallWrapper_Invalidate(t *testing.T) {
+	client := &mockCache{data: map[string]string{}}
+	err := register(t, "TestCallWrapper_Invalidate", Options{
+		Cache:       true,
+		CacheClient: client,
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	cw := NewTyped[int]("TestCallWrapper_Invalidate")
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		_, _ = cw.Call(ctx, map[string]interface{}{"id": i}, func() (int, error) {
+			return i, nil
+		})
+	}
+
+	if err := cw.Invalidate(ctx, map[string]interface{}{"id": 1}); err != nil {
+		t.Fatalf("Invalidate() failed: %v", err)
+	}
+	if len(client.data) != 2 {
+		t.Fatalf("cache size = %d, want 2", len(client.data))
+	}
+	if err := cw.InvalidateByPrefix(ctx, ""); err != nil {
+		t.Fatalf("InvalidateByPrefix() failed: %v", err)
+	}
+	if len(client.data) != 0 {
+		t.Fatalf("cache size = %d, want 0", len(client.data))
+	}
+}
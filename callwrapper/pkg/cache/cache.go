@@ -2,10 +2,36 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrCacheMiss is the error returned by Client.Get when the key is not found.
+	ErrCacheMiss = errors.New("cache missed")
+	// ErrNotSupported is the error returned when the client does not support the operation.
+	ErrNotSupported = errors.New("operation is not supported by cache client")
+)
+
 type Client interface {
+	// Get returns the value stored on the key.
+	// It must return ErrCacheMiss when the key is not found.
 	Get(ctx context.Context, key string) (interface{}, error)
 	Set(ctx context.Context, key string, val interface{}, exp time.Duration) error
 }
+
+// Deleter is the optional interface of the Client to delete the keys.
+type Deleter interface {
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// PrefixDeleter is the optional interface of the Client to delete every key that starts with the prefix.
+type PrefixDeleter interface {
+	DeleteByPrefix(ctx context.Context, prefix string) error
+}
+
+// TTLGetter is the optional interface of the Client to get the value with its remaining expiration time.
+// The expiration time is 0 when the key has no expiration.
+type TTLGetter interface {
+	GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error)
+}
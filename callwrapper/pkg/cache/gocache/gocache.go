@@ -2,37 +2,292 @@ package gocache
 
 import (
 	"context"
-	"errors"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aidapedia/gdk/callwrapper/pkg/cache"
+	"github.com/bytedance/sonic"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	memcache "github.com/patrickmn/go-cache"
 )
 
+// DefaultInvalidationChannel is the default pub/sub channel of the invalidation message.
+const DefaultInvalidationChannel = "callwrapper:cache:invalidation"
+
+// versionSlots is the number of the version counters. The keys are spread across them by hash.
+const versionSlots = 256
+
+// Client is the multi-tier cache client.
+// L1 is the in-memory cache, and L2 is the optional shared cache like redis.
+// The L1 of other instances is invalidated by redis pub/sub when PubSub is set.
 type Client struct {
+	id    string
 	cache *memcache.Cache
 	redis cache.Client
+	opt   Options
+
+	pubsub *redis.PubSub
+	wg     sync.WaitGroup
+
+	// versions is bumped on every write and invalidation of the keys hashed to it, so the value read from
+	// the shared tier during the invalidation is not stored to the in-memory tier.
+	versions [versionSlots]atomic.Uint64
 }
 
+// Options is the configuration for the multi-tier cache client.
+type Options struct {
+	// L1Expiration is the maximum expiration time of the in-memory tier. Default is 1 minute.
+	// The expiration given to Set is used when it is shorter.
+	L1Expiration time.Duration
+
+	// L1ReadThroughExpiration is the maximum expiration time of the value read from the shared tier
+	// when L2 does not implement cache.TTLGetter. Default is 10 seconds, or L1Expiration when it is shorter.
+	// The remaining expiration time of the shared tier is used when it is known.
+	L1ReadThroughExpiration time.Duration
+
+	// L1Purge is the interval to purge the expired in-memory value. Default is 10 minutes.
+	L1Purge time.Duration
+
+	// L2 is the shared cache tier. Optional.
+	L2 cache.Client
+
+	// L2Expiration overrides the expiration time of the shared tier. Default is 0 (use the expiration given to Set).
+	L2Expiration time.Duration
+
+	// PubSub is the redis client to broadcast and receive the invalidation message. Optional.
+	PubSub *redis.Client
+
+	// InvalidationChannel is the pub/sub channel of the invalidation message. Default is DefaultInvalidationChannel.
+	InvalidationChannel string
+
+	// OnError is called when the invalidation message fails to be published or handled. Optional.
+	OnError func(ctx context.Context, err error)
+}
+
+// message is the invalidation message.
+type message struct {
+	// Sender is the id of the client that publishes the message.
+	Sender string `json:"sender"`
+	// Keys is the deleted keys. The message deletes by Prefix when it is empty.
+	Keys   []string `json:"keys,omitempty"`
+	Prefix string   `json:"prefix,omitempty"`
+}
+
+// New creates a new multi-tier cache client without invalidation broadcast.
 func New(exp, purge time.Duration, redis cache.Client) cache.Client {
-	return &Client{
-		cache: memcache.New(exp, purge),
-		redis: redis,
+	cli, _ := NewWithOptions(Options{
+		L1Expiration: exp,
+		L1Purge:      purge,
+		L2:           redis,
+	})
+	return cli
+}
+
+// NewWithOptions creates a new multi-tier cache client.
+// Call Close to stop receiving the invalidation message.
+func NewWithOptions(opt Options) (*Client, error) {
+	if opt.L1Expiration == 0 {
+		opt.L1Expiration = time.Minute
+	}
+	if opt.L1ReadThroughExpiration == 0 {
+		opt.L1ReadThroughExpiration = time.Second * 10
+	}
+	if opt.L1ReadThroughExpiration > opt.L1Expiration {
+		opt.L1ReadThroughExpiration = opt.L1Expiration
 	}
+	if opt.L1Purge == 0 {
+		opt.L1Purge = time.Minute * 10
+	}
+	if opt.InvalidationChannel == "" {
+		opt.InvalidationChannel = DefaultInvalidationChannel
+	}
+	if opt.OnError == nil {
+		opt.OnError = func(ctx context.Context, err error) {}
+	}
+
+	c := &Client{
+		id:    uuid.NewString(),
+		cache: memcache.New(opt.L1Expiration, opt.L1Purge),
+		redis: opt.L2,
+		opt:   opt,
+	}
+
+	if opt.PubSub != nil {
+		ctx := context.Background()
+		c.pubsub = opt.PubSub.Subscribe(ctx, opt.InvalidationChannel)
+		// Wait for the subscription to be confirmed, so no message is lost after New returns.
+		if _, err := c.pubsub.Receive(ctx); err != nil {
+			c.pubsub.Close()
+			return nil, err
+		}
+		c.wg.Add(1)
+		go c.subscribe()
+	}
+	return c, nil
+}
+
+// Close stops receiving the invalidation message.
+func (c *Client) Close() error {
+	if c.pubsub == nil {
+		return nil
+	}
+	err := c.pubsub.Close()
+	c.wg.Wait()
+	return err
 }
 
+// Get reads the in-memory tier, then the shared tier.
+// The value from the shared tier is stored to the in-memory tier until it expires on the shared tier,
+// unless the key is written or invalidated while it is read.
 func (c *Client) Get(ctx context.Context, key string) (interface{}, error) {
 	resp, isSuccess := c.cache.Get(key)
-	if !isSuccess {
-		if c.redis == nil {
-			return nil, errors.New("cache missed")
-		}
-		return c.redis.Get(ctx, key)
+	if isSuccess {
+		return resp, nil
+	}
+	if c.redis == nil {
+		return nil, cache.ErrCacheMiss
+	}
+
+	version := c.version(key)
+	before := version.Load()
+	var (
+		exp time.Duration
+		err error
+	)
+	if getter, ok := c.redis.(cache.TTLGetter); ok {
+		resp, exp, err = getter.GetWithTTL(ctx, key)
+		exp = c.l1Expiration(exp)
+	} else {
+		resp, err = c.redis.Get(ctx, key)
+		exp = c.opt.L1ReadThroughExpiration
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version.Load() != before {
+		return resp, nil
+	}
+	c.cache.Set(key, resp, exp)
+	// The invalidation between the check and the write may miss the written value, so it is checked again.
+	if version.Load() != before {
+		c.cache.Delete(key)
 	}
 	return resp, nil
 }
 
+// Set stores the value to every tier and invalidates the in-memory tier of other instances.
 func (c *Client) Set(ctx context.Context, key string, val interface{}, exp time.Duration) error {
-	c.cache.Set(key, val, exp)
-	return c.redis.Set(ctx, key, val, exp)
+	c.version(key).Add(1)
+	c.cache.Set(key, val, c.l1Expiration(exp))
+	if c.redis != nil {
+		l2Exp := exp
+		if c.opt.L2Expiration > 0 {
+			l2Exp = c.opt.L2Expiration
+		}
+		if err := c.redis.Set(ctx, key, val, l2Exp); err != nil {
+			return err
+		}
+	}
+	c.publish(ctx, message{Keys: []string{key}})
+	return nil
+}
+
+func (c *Client) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	c.deleteKeys(keys)
+	if deleter, ok := c.redis.(cache.Deleter); ok {
+		if err := deleter.Delete(ctx, keys...); err != nil {
+			return err
+		}
+	}
+	c.publish(ctx, message{Keys: keys})
+	return nil
+}
+
+func (c *Client) DeleteByPrefix(ctx context.Context, prefix string) error {
+	c.deleteByPrefix(prefix)
+	if deleter, ok := c.redis.(cache.PrefixDeleter); ok {
+		if err := deleter.DeleteByPrefix(ctx, prefix); err != nil {
+			return err
+		}
+	}
+	c.publish(ctx, message{Prefix: prefix})
+	return nil
+}
+
+func (c *Client) deleteKeys(keys []string) {
+	for _, key := range keys {
+		c.version(key).Add(1)
+		c.cache.Delete(key)
+	}
+}
+
+func (c *Client) deleteByPrefix(prefix string) {
+	// The key being read may not be on the in-memory tier yet, so every version is bumped.
+	for i := range c.versions {
+		c.versions[i].Add(1)
+	}
+	for key := range c.cache.Items() {
+		if strings.HasPrefix(key, prefix) {
+			c.cache.Delete(key)
+		}
+	}
+}
+
+// l1Expiration returns the shorter expiration between the given one and L1Expiration.
+func (c *Client) l1Expiration(exp time.Duration) time.Duration {
+	if exp <= 0 || exp > c.opt.L1Expiration {
+		return c.opt.L1Expiration
+	}
+	return exp
+}
+
+// version returns the version counter of the key.
+func (c *Client) version(key string) *atomic.Uint64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &c.versions[h.Sum32()%versionSlots]
+}
+
+// publish broadcasts the invalidation message to other instances.
+func (c *Client) publish(ctx context.Context, msg message) {
+	if c.pubsub == nil {
+		return
+	}
+	msg.Sender = c.id
+	payload, err := sonic.MarshalString(msg)
+	if err != nil {
+		c.opt.OnError(ctx, err)
+		return
+	}
+	if err := c.opt.PubSub.Publish(ctx, c.opt.InvalidationChannel, payload).Err(); err != nil {
+		c.opt.OnError(ctx, err)
+	}
+}
+
+// subscribe receives the invalidation message until the client is closed.
+// The go-redis pub/sub reconnects automatically when the connection is lost.
+func (c *Client) subscribe() {
+	defer c.wg.Done()
+	for m := range c.pubsub.Channel() {
+		var msg message
+		if err := sonic.UnmarshalString(m.Payload, &msg); err != nil {
+			c.opt.OnError(context.Background(), err)
+			continue
+		}
+		if msg.Sender == c.id {
+			continue
+		}
+		if len(msg.Keys) == 0 {
+			c.deleteByPrefix(msg.Prefix)
+			continue
+		}
+		c.deleteKeys(msg.Keys)
+	}
 }
@@ -0,0 +1,146 @@
+package gocache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/callwrapper/pkg/cache"
+)
+
+// l2 is the shared tier that counts the Get call.
+type l2 struct {
+	data map[string]interface{}
+	gets int
+	// onGet is called on every Get before the value is returned.
+	onGet func()
+}
+
+func (c *l2) Get(ctx context.Context, key string) (interface{}, error) {
+	c.gets++
+	if c.onGet != nil {
+		c.onGet()
+	}
+	val, ok := c.data[key]
+	if !ok {
+		return nil, cache.ErrCacheMiss
+	}
+	return val, nil
+}
+
+func (c *l2) Set(ctx context.Context, key string, val interface{}, exp time.Duration) error {
+	c.data[key] = val
+	return nil
+}
+
+func TestClient_ReadThrough(t *testing.T) {
+	shared := &l2{data: map[string]interface{}{"key": "value"}}
+	cli, err := NewWithOptions(Options{L2: shared})
+	if err != nil {
+		t.Fatalf("NewWithOptions() failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		got, err := cli.Get(ctx, "key")
+		if err != nil || got != "value" {
+			t.Fatalf("Get() = %v, %v, want value", got, err)
+		}
+	}
+	if shared.gets != 1 {
+		t.Errorf("L2 Get called %d times, want 1", shared.gets)
+	}
+}
+
+// ttlL2 is the shared tier that knows the remaining expiration time.
+type ttlL2 struct {
+	l2
+	ttl time.Duration
+}
+
+func (c *ttlL2) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	val, err := c.Get(ctx, key)
+	return val, c.ttl, err
+}
+
+func TestClient_ReadThroughExpiration(t *testing.T) {
+	tests := []struct {
+		name   string
+		shared cache.Client
+		want   time.Duration
+	}{
+		{
+			name:   "remaining TTL of L2",
+			shared: &ttlL2{l2: l2{data: map[string]interface{}{"key": "value"}}, ttl: 5 * time.Second},
+			want:   5 * time.Second,
+		},
+		{
+			name:   "L2 without expiration",
+			shared: &ttlL2{l2: l2{data: map[string]interface{}{"key": "value"}}},
+			want:   time.Minute,
+		},
+		{
+			name:   "unknown TTL of L2",
+			shared: &l2{data: map[string]interface{}{"key": "value"}},
+			want:   10 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, err := NewWithOptions(Options{L2: tt.shared})
+			if err != nil {
+				t.Fatalf("NewWithOptions() failed: %v", err)
+			}
+			if _, err := cli.Get(context.Background(), "key"); err != nil {
+				t.Fatalf("Get() failed: %v", err)
+			}
+			_, expireAt, ok := cli.cache.GetWithExpiration("key")
+			if !ok {
+				t.Fatal("value is not stored to L1")
+			}
+			if got := time.Until(expireAt); got > tt.want || got < tt.want-time.Second {
+				t.Errorf("L1 expiration = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_InvalidateDuringRead(t *testing.T) {
+	shared := &l2{data: map[string]interface{}{"key": "stale"}}
+	cli, err := NewWithOptions(Options{L2: shared})
+	if err != nil {
+		t.Fatalf("NewWithOptions() failed: %v", err)
+	}
+	ctx := context.Background()
+	// The invalidation of another instance arrives while the stale value is read.
+	shared.onGet = func() {
+		cli.deleteKeys([]string{"key"})
+	}
+	if got, err := cli.Get(ctx, "key"); err != nil || got != "stale" {
+		t.Fatalf("Get() = %v, %v, want stale", got, err)
+	}
+	if _, ok := cli.cache.Get("key"); ok {
+		t.Error("value read during the invalidation is stored to L1")
+	}
+}
+
+func TestClient_WithoutL2(t *testing.T) {
+	cli := New(time.Minute, time.Minute, nil)
+	ctx := context.Background()
+
+	if _, err := cli.Get(ctx, "key"); err != cache.ErrCacheMiss {
+		t.Fatalf("Get() error = %v, want %v", err, cache.ErrCacheMiss)
+	}
+	if err := cli.Set(ctx, "key", "value", time.Minute); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if got, err := cli.Get(ctx, "key"); err != nil || got != "value" {
+		t.Fatalf("Get() = %v, %v, want value", got, err)
+	}
+	if err := cli.(cache.PrefixDeleter).DeleteByPrefix(ctx, "k"); err != nil {
+		t.Fatalf("DeleteByPrefix() failed: %v", err)
+	}
+	if _, err := cli.Get(ctx, "key"); err != cache.ErrCacheMiss {
+		t.Errorf("Get() error = %v, want %v", err, cache.ErrCacheMiss)
+	}
+}
@@ -2,12 +2,18 @@ package goredis
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/aidapedia/gdk/callwrapper/pkg/cache"
 	"github.com/go-redis/redis/v8"
 )
 
+// scanCount is the number of keys scanned and deleted on each batch.
+const scanCount = 100
+
+var patternEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
 type Client struct {
 	*redis.Client
 }
@@ -19,8 +25,69 @@ func New(opt *redis.Options) cache.Client {
 }
 
 func (c *Client) Get(ctx context.Context, key string) (interface{}, error) {
-	return c.Client.Get(ctx, key).Result()
+	resp, err := c.Client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, cache.ErrCacheMiss
+	}
+	return resp, err
 }
+
+// GetWithTTL returns the value with its remaining expiration time in one round trip.
+func (c *Client) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	var (
+		get *redis.StringCmd
+		ttl *redis.DurationCmd
+	)
+	_, err := c.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		ttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err == redis.Nil {
+		return nil, 0, cache.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	exp := ttl.Val()
+	if exp < 0 {
+		// The key has no expiration.
+		exp = 0
+	}
+	return get.Val(), exp, nil
+}
+
 func (c *Client) Set(ctx context.Context, key string, val interface{}, exp time.Duration) error {
 	return c.Client.Set(ctx, key, val, exp).Err()
 }
+
+func (c *Client) Delete(ctx context.Context, keys ...string) error {
+	return c.Client.Del(ctx, keys...).Err()
+}
+
+// DeleteByPrefix scans the keys that starts with the prefix and delete them.
+func (c *Client) DeleteByPrefix(ctx context.Context, prefix string) error {
+	iter := c.Client.Scan(ctx, 0, escapePattern(prefix)+"*", scanCount).Iterator()
+	keys := make([]string, 0, scanCount)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) >= scanCount {
+			if err := c.Client.Unlink(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return c.Client.Unlink(ctx, keys...).Err()
+	}
+	return nil
+}
+
+// escapePattern escapes the glob character of the redis pattern.
+func escapePattern(pattern string) string {
+	return patternEscaper.Replace(pattern)
+}
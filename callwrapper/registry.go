@@ -0,0 +1,102 @@
+package callwrapper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrNotFound is the error returned when the callwrapper name is not registered.
+var ErrNotFound = errors.New("callwrapper not found")
+
+// defaultRegistry is the registry used by New and GetCallWrapper.
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry used by New and GetCallWrapper.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Registry is the concurrency-safe collection of callwrapper by name.
+// The name will be used to generate the cache key. So make sure the name is unique.
+type Registry struct {
+	mu           sync.RWMutex
+	callWrappers map[string]*CallWrapper
+}
+
+// NewRegistry creates a new registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		callWrappers: make(map[string]*CallWrapper),
+	}
+}
+
+// Register creates a new callwrapper with the given name.
+// It returns error if the name already exists.
+func (r *Registry) Register(name string, opt Options) error {
+	cw, err := newCallWrapper(name, opt)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Check if the callwrapper name already exists.
+	if _, ok := r.callWrappers[name]; ok {
+		return fmt.Errorf("callwrapper name %s already exists", name)
+	}
+	r.callWrappers[name] = cw
+	return nil
+}
+
+// Get returns the callwrapper by name.
+// It returns ErrNotFound if the name is not registered.
+func (r *Registry) Get(name string) (*CallWrapper, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cw, ok := r.callWrappers[name]
+	if !ok {
+		return nil, fmt.Errorf("callwrapper name %s: %w", name, ErrNotFound)
+	}
+	return cw, nil
+}
+
+// Update replaces the options of the callwrapper at runtime.
+// The in-flight call keeps using the previous options, and the circuit breaker state is reset.
+// The bulkhead slots and the rate limit tokens are kept when MaxConcurrent and the rate limit are unchanged.
+//
+// Example: update the options when the feature flag changes.
+//
+//	callwrapper.DefaultRegistry().Update("GetUserByID", callwrapper.Options{Cache: ff.GetBool(ctx, "cache")})
+func (r *Registry) Update(name string, opt Options) error {
+	cw, err := r.Get(name)
+	if err != nil {
+		return err
+	}
+	return cw.setOptions(opt)
+}
+
+// Unregister removes the callwrapper by name.
+// It returns ErrNotFound if the name is not registered.
+func (r *Registry) Unregister(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.callWrappers[name]; !ok {
+		return fmt.Errorf("callwrapper name %s: %w", name, ErrNotFound)
+	}
+	delete(r.callWrappers, name)
+	return nil
+}
+
+// List returns the sorted name of the registered callwrapper.
+func (r *Registry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.callWrappers))
+	for name := range r.callWrappers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
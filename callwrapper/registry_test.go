@@ -0,0 +1,96 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := r.Register(fmt.Sprintf("wrapper-%d", i), Options{}); err != nil {
+				t.Errorf("Register() failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(r.List()); got != 10 {
+		t.Fatalf("List() length = %d, want 10", got)
+	}
+	if err := r.Register("wrapper-0", Options{}); err == nil {
+		t.Error("Register() duplicate name succeeded unexpectedly")
+	}
+	if _, err := r.Get("unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+	}
+
+	cw, err := r.Get("wrapper-0")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if err := r.Update("wrapper-0", Options{Singleflight: true}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if !cw.Options().Singleflight {
+		t.Error("Options().Singleflight = false, want updated value true")
+	}
+	resp, err := cw.Call(context.Background(), nil, func() (interface{}, error) {
+		return "ok", nil
+	})
+	if err != nil || resp != "ok" {
+		t.Errorf("Call() = %v, %v, want ok", resp, err)
+	}
+
+	if err := r.Unregister("wrapper-0"); err != nil {
+		t.Fatalf("Unregister() failed: %v", err)
+	}
+	want := []string{"wrapper-1", "wrapper-2", "wrapper-3", "wrapper-4", "wrapper-5", "wrapper-6", "wrapper-7", "wrapper-8", "wrapper-9"}
+	if got := r.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
+
+func TestRegistry_UpdateKeepsBulkhead(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("wrapper", Options{Bulkhead: BulkheadOptions{MaxConcurrent: 1}}); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	cw, _ := r.Get("wrapper")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cw.Call(context.Background(), nil, func() (interface{}, error) {
+			close(started)
+			<-release
+			return "ok", nil
+		})
+	}()
+	<-started
+
+	if err := r.Update("wrapper", Options{Singleflight: true, Bulkhead: BulkheadOptions{MaxConcurrent: 1}}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	// The in-flight call still holds the only slot after the update.
+	var rejected *RejectedError
+	_, err := cw.Call(context.Background(), nil, func() (interface{}, error) {
+		return "ok", nil
+	})
+	if !errors.As(err, &rejected) || rejected.Reason != RejectReasonBulkhead {
+		t.Errorf("Call() error = %v, want %v", err, RejectReasonBulkhead)
+	}
+	close(release)
+	<-done
+}
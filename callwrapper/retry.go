@@ -0,0 +1,122 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	gerr "github.com/aidapedia/gdk/error"
+	"github.com/aidapedia/gdk/util"
+)
+
+// RetryOptions is the configuration for the retry policy.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of attempts including the first call. Default is 1 (no retry).
+	MaxAttempts int
+
+	// InitialBackoff is the wait time before the first retry. Default is 100 milliseconds.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum wait time between retries. Default is 5 seconds.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor to increase the backoff on each retry. Default is 2.
+	Multiplier float64
+
+	// Jitter is the fraction (0 to 1) to randomize the backoff. Default is 0 (disabled).
+	// For example, 0.2 means the backoff is randomized between 80% and 120%.
+	Jitter float64
+
+	// AttemptTimeout is the timeout of each attempt. Default is 0 (disabled).
+	// The context of the attempt is canceled after the timeout, and the next attempt starts after the function returns,
+	// so the attempts never overlap and hold the bulkhead slot until they end.
+	// It only applies to the function that takes the context: CallContext, Typed.CallContext and Batch.
+	AttemptTimeout time.Duration
+
+	// IsRetryable decides whether the error can be retried.
+	// Default retries every error except context cancellation, circuit breaker error
+	// and gdk error with retryable metadata set to false.
+	IsRetryable func(err error) bool
+}
+
+func (o *RetryOptions) setDefault() {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 1
+	}
+	if o.InitialBackoff == 0 {
+		o.InitialBackoff = time.Millisecond * 100
+	}
+	if o.MaxBackoff == 0 {
+		o.MaxBackoff = time.Second * 5
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = 2
+	}
+	if o.IsRetryable == nil {
+		o.IsRetryable = isRetryable
+	}
+}
+
+// isRetryable is the default retry classifier.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || isCircuitError(err) {
+		return false
+	}
+	var ers *gerr.Error
+	if errors.As(err, &ers) {
+		if val := ers.GetMetadataValue(gerr.MetadataKeyRetryable); val != nil {
+			return util.ToBool(val)
+		}
+	}
+	return true
+}
+
+// backoff returns the wait time before the given retry. The first retry is 1.
+func (o *RetryOptions) backoff(retry int) time.Duration {
+	backoff := float64(o.InitialBackoff) * math.Pow(o.Multiplier, float64(retry-1))
+	if backoff > float64(o.MaxBackoff) {
+		backoff = float64(o.MaxBackoff)
+	}
+	if o.Jitter > 0 {
+		backoff = backoff * (1 + o.Jitter*(rand.Float64()*2-1))
+	}
+	return time.Duration(backoff)
+}
+
+// retry calls the function until it succeeds or the retry policy is exhausted.
+// It returns the number of attempts.
+func (cw *wrapper) retry(ctx context.Context, fn callFunc) (resp interface{}, attempts int, err error) {
+	for attempts = 1; ; attempts++ {
+		resp, err = cw.run(ctx, fn)
+		if err == nil || attempts >= cw.opt.Retry.MaxAttempts || !cw.opt.Retry.IsRetryable(err) {
+			return resp, attempts, err
+		}
+
+		timer := time.NewTimer(cw.opt.Retry.backoff(attempts))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, attempts, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt calls the function with the context of the attempt timeout.
+// It waits for the function to return, so the attempt never outlives the bulkhead slot.
+func (cw *wrapper) attempt(ctx context.Context, fn callFunc) (interface{}, error) {
+	if cw.opt.Retry.AttemptTimeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctxAttempt, cancel := context.WithTimeout(ctx, cw.opt.Retry.AttemptTimeout)
+	defer cancel()
+	resp, err := fn(ctxAttempt)
+	if err != nil && ctx.Err() == nil && errors.Is(ctxAttempt.Err(), context.DeadlineExceeded) {
+		// The function may return its own error on the canceled context, report the timeout instead.
+		return nil, context.DeadlineExceeded
+	}
+	return resp, err
+}
@@ -0,0 +1,116 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gerr "github.com/aidapedia/gdk/error"
+)
+
+func TestCallWrapper_Retry(t *testing.T) {
+	var attempts interface{}
+	err := register(t, "TestCallWrapper_Retry", Options{
+		Retry: RetryOptions{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		},
+		Hook: Hook{
+			AfterHook: func(ctx context.Context, param map[string]interface{}) {
+				attempts = param[HookParamAttempts]
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var called int
+	resp, err := GetCallWrapper("TestCallWrapper_Retry").Call(context.Background(), nil, func() (interface{}, error) {
+		called++
+		if called < 3 {
+			return nil, errors.New("temporary error")
+		}
+		return "ok", nil
+	})
+	if err != nil || resp != "ok" {
+		t.Fatalf("Call() = %v, %v, want ok", resp, err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %v, want 3", attempts)
+	}
+
+	called = 0
+	_, err = GetCallWrapper("TestCallWrapper_Retry").Call(context.Background(), nil, func() (interface{}, error) {
+		called++
+		return nil, gerr.NewWithMetadata(errors.New("bad request"), gerr.Metadata{
+			gerr.MetadataKeyRetryable: false,
+		})
+	})
+	if err == nil || called != 1 {
+		t.Errorf("Call() called %d times with error %v, want 1 non-retryable call", called, err)
+	}
+}
+
+func TestCallWrapper_AttemptTimeout(t *testing.T) {
+	err := register(t, "TestCallWrapper_AttemptTimeout", Options{
+		Retry: RetryOptions{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			AttemptTimeout: 10 * time.Millisecond,
+		},
+		Bulkhead: BulkheadOptions{
+			MaxConcurrent: 1,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var running, maxRunning, called atomic.Int32
+	_, err = GetCallWrapper("TestCallWrapper_AttemptTimeout").CallContext(context.Background(), nil, func(ctx context.Context) (interface{}, error) {
+		called.Add(1)
+		n := running.Add(1)
+		defer running.Add(-1)
+		if n > maxRunning.Load() {
+			maxRunning.Store(n)
+		}
+		<-ctx.Done()
+		// The function takes a while to stop after the cancellation.
+		time.Sleep(5 * time.Millisecond)
+		return nil, errors.New("canceled")
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CallContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if called.Load() != 3 {
+		t.Errorf("called %d times, want 3", called.Load())
+	}
+	if maxRunning.Load() != 1 {
+		t.Errorf("max running attempts = %d, want 1", maxRunning.Load())
+	}
+}
+
+func TestRetryOptions_backoff(t *testing.T) {
+	opt := RetryOptions{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	opt.setDefault()
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{retry: 1, want: 100 * time.Millisecond},
+		{retry: 2, want: 200 * time.Millisecond},
+		{retry: 3, want: 400 * time.Millisecond},
+		{retry: 5, want: time.Second},
+	}
+	for _, tt := range tests {
+		if got := opt.backoff(tt.retry); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.retry, got, tt.want)
+		}
+	}
+}
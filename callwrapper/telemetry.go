@@ -0,0 +1,131 @@
+package callwrapper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// meterName is the instrumentation scope of the callwrapper metrics.
+	meterName = "github.com/aidapedia/gdk/callwrapper"
+
+	attrName   = "callwrapper.name"
+	attrResult = "result"
+	attrReason = "reason"
+
+	resultSuccess = "success"
+	resultError   = "error"
+	resultHit     = "hit"
+	resultMiss    = "miss"
+)
+
+// metrics is the OpenTelemetry instruments of the callwrapper.
+// All methods are safe to call on nil metrics.
+type metrics struct {
+	attr attribute.KeyValue
+
+	calls    metric.Int64Counter
+	duration metric.Float64Histogram
+	cache    metric.Int64Counter
+	shared   metric.Int64Counter
+	inflight metric.Int64UpDownCounter
+	rejected metric.Int64Counter
+}
+
+func newMetrics(name string) (*metrics, error) {
+	meter := otel.Meter(meterName)
+	m := &metrics{
+		attr: attribute.String(attrName, name),
+	}
+
+	var err error
+	m.calls, err = meter.Int64Counter("callwrapper.calls",
+		metric.WithDescription("Number of call"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.duration, err = meter.Float64Histogram("callwrapper.duration",
+		metric.WithDescription("Duration of call"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.cache, err = meter.Int64Counter("callwrapper.cache",
+		metric.WithDescription("Number of cache lookup by result hit, miss and error"),
+		metric.WithUnit("{lookup}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.shared, err = meter.Int64Counter("callwrapper.singleflight.shared",
+		metric.WithDescription("Number of call that share the result by singleflight"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.inflight, err = meter.Int64UpDownCounter("callwrapper.inflight",
+		metric.WithDescription("Number of call in flight"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.rejected, err = meter.Int64Counter("callwrapper.rejected",
+		metric.WithDescription("Number of call rejected by the bulkhead and rate limit"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// start records the beginning of the call and returns the function to record the end of the call.
+func (m *metrics) start(ctx context.Context) func(err error) {
+	if m == nil {
+		return func(err error) {}
+	}
+	begin := time.Now()
+	m.inflight.Add(ctx, 1, metric.WithAttributes(m.attr))
+	return func(err error) {
+		result := resultSuccess
+		if err != nil {
+			result = resultError
+		}
+		opt := metric.WithAttributes(m.attr, attribute.String(attrResult, result))
+		m.inflight.Add(ctx, -1, metric.WithAttributes(m.attr))
+		m.calls.Add(ctx, 1, opt)
+		m.duration.Record(ctx, time.Since(begin).Seconds(), opt)
+	}
+}
+
+func (m *metrics) cacheLookup(ctx context.Context, result string) {
+	if m == nil {
+		return
+	}
+	m.cache.Add(ctx, 1, metric.WithAttributes(m.attr, attribute.String(attrResult, result)))
+}
+
+func (m *metrics) sharedCall(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	m.shared.Add(ctx, 1, metric.WithAttributes(m.attr))
+}
+
+func (m *metrics) rejectedCall(ctx context.Context, err error) {
+	var rejected *RejectedError
+	if m == nil || !errors.As(err, &rejected) {
+		return
+	}
+	m.rejected.Add(ctx, 1, metric.WithAttributes(m.attr, attribute.String(attrReason, string(rejected.Reason))))
+}
@@ -0,0 +1,139 @@
+package callwrapper
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestCallWrapper_Metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+
+	name := "TestCallWrapper_Metrics"
+	err := register(t, name, Options{
+		Cache:        true,
+		CacheClient:  &mockCache{data: map[string]string{}},
+		Singleflight: true,
+		Metrics:      true,
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	ctx := context.Background()
+	cw := GetCallWrapper(name)
+	fn := func() (interface{}, error) {
+		return "ok", nil
+	}
+
+	// The first call misses the cache, and the second one hits it.
+	for i := 0; i < 2; i++ {
+		if _, err := cw.Call(ctx, map[string]interface{}{"id": 1}, fn); err != nil {
+			t.Fatalf("Call() failed: %v", err)
+		}
+	}
+
+	// The concurrent calls of the same key share one result, and singleflight reports both of them as shared.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	blocking := func() (interface{}, error) {
+		close(started)
+		<-release
+		return "ok", nil
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = cw.Call(ctx, map[string]interface{}{"id": 2}, blocking)
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		_, _ = cw.Call(ctx, map[string]interface{}{"id": 2}, blocking)
+	}()
+	// Wait for the second call to join the first one.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+	tests := []struct {
+		metric string
+		attrs  []attribute.KeyValue
+		want   int64
+	}{
+		{
+			metric: "callwrapper.calls",
+			attrs:  []attribute.KeyValue{attribute.String(attrName, name), attribute.String(attrResult, resultSuccess)},
+			want:   4,
+		},
+		{
+			metric: "callwrapper.cache",
+			attrs:  []attribute.KeyValue{attribute.String(attrName, name), attribute.String(attrResult, resultHit)},
+			want:   1,
+		},
+		{
+			metric: "callwrapper.cache",
+			attrs:  []attribute.KeyValue{attribute.String(attrName, name), attribute.String(attrResult, resultMiss)},
+			want:   3,
+		},
+		{
+			metric: "callwrapper.singleflight.shared",
+			attrs:  []attribute.KeyValue{attribute.String(attrName, name)},
+			want:   2,
+		},
+		{
+			metric: "callwrapper.inflight",
+			attrs:  []attribute.KeyValue{attribute.String(attrName, name)},
+			want:   0,
+		},
+	}
+	for _, tt := range tests {
+		attrs := attribute.NewSet(tt.attrs...)
+		label := tt.metric + "{" + attrs.Encoded(attribute.DefaultEncoder()) + "}"
+		got, ok := sumValue(rm, tt.metric, attrs)
+		if !ok {
+			t.Errorf("%s is not recorded", label)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %d, want %d", label, got, tt.want)
+		}
+	}
+}
+
+// sumValue returns the value of the int64 sum data point of the metric with the attributes.
+func sumValue(rm metricdata.ResourceMetrics, name string, attrs attribute.Set) (int64, bool) {
+	for _, sm := range rm.ScopeMetrics {
+		if sm.Scope.Name != meterName {
+			continue
+		}
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				return 0, false
+			}
+			for _, dp := range sum.DataPoints {
+				if dp.Attributes.Equals(&attrs) {
+					return dp.Value, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
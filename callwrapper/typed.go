@@ -0,0 +1,74 @@
+package callwrapper
+
+import "context"
+
+// Typed is the type-safe callwrapper.
+// It decodes the cached value into T, so cache hit and fresh call return the same type.
+type Typed[T any] struct {
+	cw *CallWrapper
+}
+
+// NewTyped returns the typed callwrapper of the registered callwrapper.
+// If the callwrapper is not found, it will return callwrapper without cache and singleflight.
+//
+// Example:
+//
+//	callwrapper.New("GetUserByID", callwrapper.Options{})
+//	user, err := callwrapper.NewTyped[User]("GetUserByID").Call(ctx, key, fn)
+func NewTyped[T any](name string) *Typed[T] {
+	cw, ok := GetCallWrapper(name).(*CallWrapper)
+	if !ok {
+		cw, _ = newCallWrapper(name, Options{})
+	}
+	return AsTyped[T](cw)
+}
+
+// AsTyped returns the typed callwrapper of the given callwrapper.
+// It is useful for callwrapper from the non-default registry.
+func AsTyped[T any](cw *CallWrapper) *Typed[T] {
+	return &Typed[T]{
+		cw: cw,
+	}
+}
+
+// Call executes the call function.
+// The function does not take the context, so Retry.AttemptTimeout can not stop it.
+// Use CallContext to stop the attempt on timeout.
+func (t *Typed[T]) Call(ctx context.Context, key map[string]interface{}, fn func() (T, error)) (resp T, err error) {
+	return t.CallContext(ctx, key, func(ctx context.Context) (T, error) {
+		return fn()
+	})
+}
+
+// CallContext executes the call function with the context of the attempt.
+// The context is canceled when Retry.AttemptTimeout is reached.
+func (t *Typed[T]) CallContext(ctx context.Context, key map[string]interface{}, fn func(ctx context.Context) (T, error)) (resp T, err error) {
+	w := t.cw.load()
+	val, err := w.call(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return fn(ctx)
+	}, func(data []byte) (interface{}, error) {
+		var val T
+		err := w.opt.Codec.Unmarshal(data, &val)
+		return val, err
+	})
+	if val != nil {
+		resp, _ = val.(T)
+	}
+	return resp, err
+}
+
+// Invalidate deletes the cached value of the key.
+func (t *Typed[T]) Invalidate(ctx context.Context, key map[string]interface{}) error {
+	return t.cw.Invalidate(ctx, key)
+}
+
+// InvalidateByPrefix deletes every cached value of the callwrapper that starts with the prefix.
+func (t *Typed[T]) InvalidateByPrefix(ctx context.Context, prefix string) error {
+	return t.cw.InvalidateByPrefix(ctx, prefix)
+}
+
+// Call executes the call function on the callwrapper with the given name and returns the typed response.
+// It is the shorthand of NewTyped[T](name).Call(ctx, key, fn).
+func Call[T any](ctx context.Context, name string, key map[string]interface{}, fn func() (T, error)) (T, error) {
+	return NewTyped[T](name).Call(ctx, key, fn)
+}
@@ -0,0 +1,156 @@
+package callwrapper
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/callwrapper/pkg/cache"
+	"github.com/aidapedia/gdk/codec"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type user struct {
+	ID   int
+	Name string
+}
+
+// mockCache stores the value as string like redis does.
+type mockCache struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (m *mockCache) Get(ctx context.Context, key string) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	val, ok := m.data[key]
+	if !ok {
+		return nil, cache.ErrCacheMiss
+	}
+	return val, nil
+}
+
+func (m *mockCache) Set(ctx context.Context, key string, val interface{}, exp time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = string(val.([]byte))
+	return nil
+}
+
+func (m *mockCache) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.data, key)
+	}
+	return nil
+}
+
+func (m *mockCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.data {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.data, key)
+		}
+	}
+	return nil
+}
+
+func TestTyped_Call(t *testing.T) {
+	err := register(t, "TestTyped_Call", Options{
+		Cache:       true,
+		CacheClient: &mockCache{data: map[string]string{}},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var called int
+	fn := func() (user, error) {
+		called++
+		return user{ID: 1, Name: "aida"}, nil
+	}
+	key := map[string]interface{}{"id": 1}
+
+	for i := 0; i < 2; i++ {
+		got, err := NewTyped[user]("TestTyped_Call").Call(context.Background(), key, fn)
+		if err != nil {
+			t.Fatalf("Call() failed: %v", err)
+		}
+		if got != (user{ID: 1, Name: "aida"}) {
+			t.Errorf("Call() = %v, want %v", got, user{ID: 1, Name: "aida"})
+		}
+	}
+	if called != 1 {
+		t.Errorf("fn called %d times, want 1", called)
+	}
+}
+
+func TestTyped_CallCodec(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec codec.Codec
+	}{
+		{name: "JSON", codec: codec.NewJSON()},
+		{name: "Gob", codec: codec.NewGob()},
+		{name: "Msgpack", codec: codec.NewMsgpack()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "TestTyped_CallCodec" + tt.name
+			err := register(t, name, Options{
+				Cache:       true,
+				CacheClient: &mockCache{data: map[string]string{}},
+				Codec:       tt.codec,
+			})
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			var called int
+			fn := func() (user, error) {
+				called++
+				return user{ID: 1, Name: "aida"}, nil
+			}
+			for i := 0; i < 2; i++ {
+				got, err := NewTyped[user](name).Call(context.Background(), nil, fn)
+				if err != nil || got != (user{ID: 1, Name: "aida"}) {
+					t.Fatalf("Call() = %v, %v, want %v", got, err, user{ID: 1, Name: "aida"})
+				}
+			}
+			if called != 1 {
+				t.Errorf("fn called %d times, want 1", called)
+			}
+		})
+	}
+}
+
+func TestTyped_CallProtobuf(t *testing.T) {
+	err := register(t, "TestTyped_CallProtobuf", Options{
+		Cache:       true,
+		CacheClient: &mockCache{data: map[string]string{}},
+		Codec:       codec.NewProtobuf(),
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var called int
+	fn := func() (*wrapperspb.StringValue, error) {
+		called++
+		return wrapperspb.String("aida"), nil
+	}
+	for i := 0; i < 2; i++ {
+		got, err := NewTyped[*wrapperspb.StringValue]("TestTyped_CallProtobuf").Call(context.Background(), nil, fn)
+		if err != nil || got.GetValue() != "aida" {
+			t.Fatalf("Call() = %v, %v, want aida", got, err)
+		}
+	}
+	if called != 1 {
+		t.Errorf("fn called %d times, want 1", called)
+	}
+}
@@ -0,0 +1,9 @@
+package codec
+
+// Codec is the serializer used by callwrapper and the cache engine to store values on the cache.
+// The same codec must be used to encode and decode the value, so make sure every
+// instance that share the same cache use the same codec.
+type Codec interface {
+	Marshal(val interface{}) ([]byte, error)
+	Unmarshal(data []byte, dest interface{}) error
+}
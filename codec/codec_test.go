@@ -0,0 +1,69 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type user struct {
+	ID   int
+	Name string
+	Tags []string
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+	}{
+		{name: "JSON", codec: NewJSON()},
+		{name: "Gob", codec: NewGob()},
+		{name: "Msgpack", codec: NewMsgpack()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := user{ID: 1, Name: "aida", Tags: []string{"a", "b"}}
+			data, err := tt.codec.Marshal(want)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			var got user
+			if err := tt.codec.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestProtobuf_RoundTrip(t *testing.T) {
+	c := NewProtobuf()
+	want := wrapperspb.String("aida")
+	data, err := c.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+
+	var msg wrapperspb.StringValue
+	if err := c.Unmarshal(data, &msg); err != nil || !proto.Equal(&msg, want) {
+		t.Errorf("Unmarshal() into message = %v, %v, want %v", &msg, err, want)
+	}
+	// The pointer to the message pointer gets a new message.
+	var ptr *wrapperspb.StringValue
+	if err := c.Unmarshal(data, &ptr); err != nil || !proto.Equal(ptr, want) {
+		t.Errorf("Unmarshal() into message pointer = %v, %v, want %v", ptr, err, want)
+	}
+
+	if _, err := c.Marshal("aida"); err == nil {
+		t.Error("Marshal() of non message succeeded unexpectedly")
+	}
+	var str string
+	if err := c.Unmarshal(data, &str); err == nil {
+		t.Error("Unmarshal() into non message succeeded unexpectedly")
+	}
+}
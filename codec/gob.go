@@ -0,0 +1,27 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// Gob is the codec that encode the value using encoding/gob.
+// Unexported fields are not encoded, and interface values must be registered with gob.Register.
+type Gob struct{}
+
+// NewGob creates a new Gob codec.
+func NewGob() Codec {
+	return Gob{}
+}
+
+func (Gob) Marshal(val interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (Gob) Unmarshal(data []byte, dest interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(dest)
+}
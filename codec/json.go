@@ -0,0 +1,20 @@
+package codec
+
+import "github.com/bytedance/sonic"
+
+// JSON is the codec that encode the value as JSON using sonic.
+// This is the default codec of callwrapper.
+type JSON struct{}
+
+// NewJSON creates a new JSON codec.
+func NewJSON() Codec {
+	return JSON{}
+}
+
+func (JSON) Marshal(val interface{}) ([]byte, error) {
+	return sonic.Marshal(val)
+}
+
+func (JSON) Unmarshal(data []byte, dest interface{}) error {
+	return sonic.Unmarshal(data, dest)
+}
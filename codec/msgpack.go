@@ -0,0 +1,20 @@
+package codec
+
+import "github.com/shamaton/msgpack/v2"
+
+// Msgpack is the codec that encode the value as MessagePack.
+// Struct is encoded as a map keyed by the field name, so the field order can change safely.
+type Msgpack struct{}
+
+// NewMsgpack creates a new Msgpack codec.
+func NewMsgpack() Codec {
+	return Msgpack{}
+}
+
+func (Msgpack) Marshal(val interface{}) ([]byte, error) {
+	return msgpack.Marshal(val)
+}
+
+func (Msgpack) Unmarshal(data []byte, dest interface{}) error {
+	return msgpack.Unmarshal(data, dest)
+}
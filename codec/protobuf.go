@@ -0,0 +1,46 @@
+package codec
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Protobuf is the codec that encode the value using protocol buffers.
+// Both the value and the destination must implement proto.Message. The destination can also be
+// the pointer to the message pointer, e.g. the *T of callwrapper.Typed[T] with T = *pb.User,
+// and the new message is allocated to it.
+type Protobuf struct{}
+
+// NewProtobuf creates a new Protobuf codec.
+func NewProtobuf() Codec {
+	return Protobuf{}
+}
+
+func (Protobuf) Marshal(val interface{}) ([]byte, error) {
+	msg, ok := val.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("codec: %T does not implement proto.Message", val)
+	}
+	return proto.Marshal(msg)
+}
+
+func (Protobuf) Unmarshal(data []byte, dest interface{}) error {
+	if msg, ok := dest.(proto.Message); ok {
+		return proto.Unmarshal(data, msg)
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Pointer {
+		return fmt.Errorf("codec: %T does not implement proto.Message", dest)
+	}
+	msg, ok := reflect.New(rv.Elem().Type().Elem()).Interface().(proto.Message)
+	if !ok {
+		return fmt.Errorf("codec: %T does not implement proto.Message", dest)
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return err
+	}
+	rv.Elem().Set(reflect.ValueOf(msg))
+	return nil
+}
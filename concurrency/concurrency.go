@@ -3,9 +3,15 @@ package concurrency
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
 
+	gctx "github.com/aidapedia/gdk/context"
 	gerr "github.com/aidapedia/gdk/error"
 	"github.com/aidapedia/gdk/log"
+	"github.com/aidapedia/gdk/telemetry/tracer"
+	"github.com/aidapedia/gdk/util"
 	"go.uber.org/zap"
 )
 
@@ -13,16 +19,26 @@ var concurrency routine
 
 type routine struct {
 	recoverHook func(ctx context.Context, err interface{})
+	// tracker counts the running routines started by Call.
+	tracker tracker
+	// stopCtx is canceled by Shutdown to cancel the context of the routines.
+	stopCtx context.Context
+	stop    context.CancelFunc
 }
 
 func init() {
+	stopCtx, stop := context.WithCancel(context.Background())
 	concurrency = routine{
-		recoverHook: func(ctx context.Context, err interface{}) {
-			log.ErrorCtx(ctx, "routine panic", zap.Any("error", err))
-		},
+		recoverHook: defaultRecoverHook,
+		stopCtx:     stopCtx,
+		stop:        stop,
 	}
 }
 
+func defaultRecoverHook(ctx context.Context, err interface{}) {
+	log.ErrorCtx(ctx, "routine panic", zap.Any("error", err))
+}
+
 // SetRecoverHook sets the recover hook for the routine.
 //
 // Custom recover hook can be used to handle panic in routine. For example: send notification
@@ -33,15 +49,76 @@ func SetRecoverHook(fn func(ctx context.Context, err interface{})) {
 // Call runs the function in a new routine.
 //
 // It is useful when you want to run a function in a new routine.
+// The context of the routine is not canceled with ctx, only by Shutdown, and Wait waits for the routine to return.
+// The routine runs in a new span linked to the span of ctx, and keeps the log ID of ctx.
 func Call(ctx context.Context, fn func(ctx context.Context)) {
-	ctxRtn := context.WithoutCancel(ctx)
-	// Capture panic to recover
+	ctxRtn, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stopCancel := context.AfterFunc(concurrency.stopCtx, cancel)
+	concurrency.tracker.add()
 	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				concurrency.recoverHook(ctxRtn, gerr.New(fmt.Errorf("%v", r)))
-			}
-		}()
-		fn(ctxRtn)
+		defer concurrency.tracker.done()
+		defer stopCancel()
+		defer cancel()
+		runDetached(ctxRtn, fn)
 	}()
 }
+
+// Wait waits for every routine started by Call to return.
+// It returns the error of ctx when ctx is done first. Call it on shutdown, so the work in progress is not lost.
+func Wait(ctx context.Context) error {
+	return concurrency.tracker.wait(ctx)
+}
+
+// Shutdown cancels the context of every routine started by Call, including the ones started after it,
+// and waits for them to return the same as Wait.
+func Shutdown(ctx context.Context) error {
+	concurrency.stop()
+	return Wait(ctx)
+}
+
+// Run runs the function in the caller routine and passes its panic to the recover hook, the same as Call.
+// It returns the error of the panic, so the caller running its own routine can report the panic to its waiter.
+func Run(ctx context.Context, fn func(ctx context.Context)) error {
+	return run(ctx, fn)
+}
+
+// runDetached runs the function detached from the caller, in a new span linked to the span of the caller.
+// The log ID is generated when the caller has none, so every log of the routine has the same one.
+func runDetached(ctx context.Context, fn func(ctx context.Context)) {
+	if util.ToStr(ctx.Value(gctx.ContextKeyLogID)) == "" {
+		ctx = context.WithValue(ctx, gctx.ContextKeyLogID, log.GenerateLogID())
+	}
+	span, ctx := tracer.StartLinkedSpanFromContext(ctx, funcName(fn))
+	err := run(ctx, fn)
+	span.Finish(err)
+}
+
+// run runs the function and passes its panic to the recover hook. It returns the error of the panic.
+func run(ctx context.Context, fn func(ctx context.Context)) (err error) {
+	// Capture panic to recover
+	defer func() {
+		if r := recover(); r != nil {
+			perr := panicError(r)
+			concurrency.recoverHook(ctx, perr)
+			err = perr
+		}
+	}()
+	fn(ctx)
+	return nil
+}
+
+// funcName returns the name of the function used as the span name, e.g. github.com/aidapedia/app.(*Handler).Notify.func1.
+func funcName(fn interface{}) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "concurrency.Call"
+}
+
+// panicError converts the recovered value to the error with the stack trace of the panic.
+// It must be called by the deferred function recovering the panic.
+func panicError(r interface{}) *gerr.Error {
+	return gerr.NewWithMetadata(fmt.Errorf("%v", r), gerr.Metadata{
+		gerr.MetadataKeyStack: string(debug.Stack()),
+	})
+}
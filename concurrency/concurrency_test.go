@@ -0,0 +1,126 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gctx "github.com/aidapedia/gdk/context"
+	"github.com/aidapedia/gdk/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWait(t *testing.T) {
+	ctx := context.Background()
+	if err := Wait(ctx); err != nil {
+		t.Fatalf("Wait() without routine failed: %v", err)
+	}
+
+	release := make(chan struct{})
+	var done atomic.Bool
+	Call(ctx, func(ctx context.Context) {
+		<-release
+		done.Store(true)
+	})
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := Wait(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() of running routine error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+	if err := Wait(ctx); err != nil || !done.Load() {
+		t.Errorf("Wait() = %v, done = %v, want the routine to finish", err, done.Load())
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Cleanup(func() {
+		concurrency.stopCtx, concurrency.stop = context.WithCancel(context.Background())
+	})
+	parent, cancelParent := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	Call(parent, func(ctx context.Context) {
+		<-ctx.Done()
+		canceled <- ctx.Err()
+	})
+	// The routine is not canceled with the context of the caller.
+	cancelParent()
+	select {
+	case err := <-canceled:
+		t.Fatalf("routine is canceled with the caller: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := Shutdown(timeout); err != nil {
+		t.Fatalf("Shutdown() failed: %v", err)
+	}
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Errorf("routine context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCall_Trace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	SetRecoverHook(func(ctx context.Context, err interface{}) {})
+	t.Cleanup(func() { SetRecoverHook(defaultRecoverHook) })
+
+	ctx, parent := otel.Tracer("").Start(context.Background(), "parent")
+	parent.End()
+	logIDs := make(chan string, 2)
+	Call(ctx, func(ctx context.Context) {
+		logIDs <- util.ToStr(ctx.Value(gctx.ContextKeyLogID))
+		logIDs <- util.ToStr(ctx.Value(gctx.ContextKeyLogID))
+		panic("boom")
+	})
+	if err := Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if first, second := <-logIDs, <-logIDs; first == "" || first != second {
+		t.Errorf("log ID = %q then %q, want the same generated one", first, second)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(spans))
+	}
+	span := spans[1]
+	if span.Parent().IsValid() {
+		t.Error("span of the routine is not a new root")
+	}
+	if links := span.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span links = %+v, want the parent span", links)
+	}
+	if span.Status().Code != codes.Error || span.Status().Description != "boom" {
+		t.Errorf("span status = %+v, want error boom", span.Status())
+	}
+}
+
+func TestRun_Recover(t *testing.T) {
+	recovered := make(chan interface{}, 1)
+	SetRecoverHook(func(ctx context.Context, err interface{}) { recovered <- err })
+	t.Cleanup(func() { SetRecoverHook(defaultRecoverHook) })
+
+	err := Run(context.Background(), func(ctx context.Context) { panic("boom") })
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("Run() error = %v, want boom", err)
+	}
+	select {
+	case <-recovered:
+	default:
+		t.Error("recover hook is not called")
+	}
+	if err := Run(context.Background(), func(ctx context.Context) {}); err != nil {
+		t.Errorf("Run() without panic error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,115 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type GroupOptions struct {
+	// Limit is the maximum number of the functions running at the same time.
+	// Default is 0, which means unlimited.
+	Limit int
+	// CollectErrors makes Wait return every error joined by errors.Join, and the failure does not
+	// cancel the other functions. Otherwise Wait returns the first error, which cancels the context of the group.
+	// Default is false.
+	CollectErrors bool
+}
+
+// Group runs the functions in new routines and waits for them, the same as errgroup.
+// The panic of the function is passed to the recover hook and returned by Wait as the error.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	opt    GroupOptions
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup creates a new Group and the context given to its functions.
+// The context is canceled when a function fails, or when Wait returns.
+func NewGroup(ctx context.Context, opt GroupOptions) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &Group{
+		ctx:    ctx,
+		cancel: cancel,
+		opt:    opt,
+	}
+	if opt.Limit > 0 {
+		g.sem = make(chan struct{}, opt.Limit)
+	}
+	return g, ctx
+}
+
+// Go runs the function in a new routine. It blocks until the function can run under the limit.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+		if err := g.call(fn); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func (g *Group) call(fn func(ctx context.Context) error) (err error) {
+	if perr := run(g.ctx, func(ctx context.Context) { err = fn(ctx) }); perr != nil {
+		return perr
+	}
+	return err
+}
+
+func (g *Group) fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.opt.CollectErrors && len(g.errs) > 0 {
+		return
+	}
+	g.errs = append(g.errs, err)
+	if !g.opt.CollectErrors {
+		g.cancel(err)
+	}
+}
+
+// Wait waits for every function and returns the first error, or every error with CollectErrors.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.opt.CollectErrors {
+		return errors.Join(g.errs...)
+	}
+	if len(g.errs) > 0 {
+		return g.errs[0]
+	}
+	return nil
+}
+
+// Map calls fn with every item in a Group and returns the results in the order of the items.
+// The result of the failed item is the zero value, and the error is returned the same as Group.Wait.
+func Map[T, R any](ctx context.Context, opt GroupOptions, items []T, fn func(ctx context.Context, item T) (R, error)) ([]R, error) {
+	results := make([]R, len(items))
+	g, _ := NewGroup(ctx, opt)
+	for i, item := range items {
+		g.Go(func(ctx context.Context) error {
+			res, err := fn(ctx, item)
+			if err != nil {
+				return err
+			}
+			results[i] = res
+			return nil
+		})
+	}
+	return results, g.Wait()
+}
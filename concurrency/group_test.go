@@ -0,0 +1,129 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gerr "github.com/aidapedia/gdk/error"
+)
+
+func TestGroup_FirstError(t *testing.T) {
+	errFirst := errors.New("first")
+	g, ctx := NewGroup(context.Background(), GroupOptions{})
+
+	g.Go(func(ctx context.Context) error { return errFirst })
+	g.Go(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return errors.New("canceled")
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+	if err := g.Wait(); !errors.Is(err, errFirst) {
+		t.Errorf("Wait() error = %v, want %v", err, errFirst)
+	}
+	if cause := context.Cause(ctx); !errors.Is(cause, errFirst) {
+		t.Errorf("context cause = %v, want %v", cause, errFirst)
+	}
+}
+
+func TestGroup_CollectErrors(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	g, ctx := NewGroup(context.Background(), GroupOptions{CollectErrors: true})
+
+	g.Go(func(ctx context.Context) error { return errA })
+	g.Go(func(ctx context.Context) error { return errB })
+	g.Go(func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return ctx.Err()
+	})
+	err := g.Wait()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Wait() error = %v, want a and b", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("context is not canceled after Wait()")
+	}
+}
+
+func TestGroup_Limit(t *testing.T) {
+	g, _ := NewGroup(context.Background(), GroupOptions{Limit: 2})
+	var running, peak atomic.Int64
+	for i := 0; i < 10; i++ {
+		g.Go(func(ctx context.Context) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("concurrent functions = %d, want at most 2", got)
+	}
+}
+
+func TestGroup_Panic(t *testing.T) {
+	hooked := make(chan interface{}, 1)
+	SetRecoverHook(func(ctx context.Context, err interface{}) { hooked <- err })
+	t.Cleanup(func() { SetRecoverHook(defaultRecoverHook) })
+
+	g, _ := NewGroup(context.Background(), GroupOptions{})
+	g.Go(func(ctx context.Context) error { panic("boom") })
+	err := g.Wait()
+
+	var ge *gerr.Error
+	if !errors.As(err, &ge) || ge.Error() != "boom" {
+		t.Fatalf("Wait() error = %v, want boom", err)
+	}
+	if stack, _ := ge.GetMetadataValue(gerr.MetadataKeyStack).(string); !strings.Contains(stack, "TestGroup_Panic") {
+		t.Errorf("stack metadata does not contain the panic site: %q", stack)
+	}
+	select {
+	case <-hooked:
+	default:
+		t.Error("recover hook is not called")
+	}
+}
+
+func TestMap(t *testing.T) {
+	ctx := context.Background()
+	items := []int{1, 2, 3, 4}
+	double := func(ctx context.Context, n int) (int, error) {
+		if n == 3 {
+			return 0, errors.New("three")
+		}
+		return n * 2, nil
+	}
+
+	got, err := Map(ctx, GroupOptions{Limit: 2, CollectErrors: true}, items, double)
+	if err == nil || err.Error() != "three" {
+		t.Errorf("Map() error = %v, want three", err)
+	}
+	want := []int{2, 4, 0, 8}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Map()[%d] = %d, want %d", i, got[i], want[i])
+		}
+	}
+
+	strs, err := Map(ctx, GroupOptions{}, []int{1, 2}, func(ctx context.Context, n int) (string, error) {
+		return strings.Repeat("a", n), nil
+	})
+	if err != nil || strs[0] != "a" || strs[1] != "aa" {
+		t.Errorf("Map() = %v, %v, want [a aa]", strs, err)
+	}
+}
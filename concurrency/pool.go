@@ -0,0 +1,146 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+var (
+	// ErrPoolFull is returned by TrySubmit when the queue of the pool is full.
+	ErrPoolFull = errors.New("pool queue is full")
+	// ErrPoolClosed is returned when the task is submitted after Shutdown.
+	ErrPoolClosed = errors.New("pool is closed")
+)
+
+type PoolOptions struct {
+	// Workers is the number of routines running the tasks.
+	// Default is runtime.GOMAXPROCS(0).
+	Workers int
+	// QueueSize is the number of the submitted tasks waiting for a free worker.
+	// Default is Workers.
+	QueueSize int
+}
+
+func (o *PoolOptions) setDefault() {
+	if o.Workers <= 0 {
+		o.Workers = runtime.GOMAXPROCS(0)
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = o.Workers
+	}
+}
+
+// Pool runs the submitted tasks on a fixed number of routines, so the burst of tasks waits in the
+// bounded queue instead of starting a routine each. The panic of the task is passed to the recover hook.
+type Pool struct {
+	tasks chan task
+	// slots holds one value for every queued task, so the submitter waits for the free space on it
+	// without holding mu, and the send to tasks never blocks.
+	slots chan struct{}
+	wg    sync.WaitGroup
+
+	// mu guards closed and the send to tasks, so tasks is not closed during the send.
+	mu     sync.RWMutex
+	closed bool
+	// done is closed by Shutdown to wake up the waiting submitters.
+	done chan struct{}
+	once sync.Once
+}
+
+type task struct {
+	ctx context.Context
+	fn  func(ctx context.Context)
+}
+
+// NewPool creates a new Pool and starts its workers. Call Shutdown to stop them.
+func NewPool(opt PoolOptions) *Pool {
+	opt.setDefault()
+	p := &Pool{
+		tasks: make(chan task, opt.QueueSize),
+		slots: make(chan struct{}, opt.QueueSize),
+		done:  make(chan struct{}),
+	}
+	p.wg.Add(opt.Workers)
+	for i := 0; i < opt.Workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for t := range p.tasks {
+		<-p.slots
+		runDetached(t.ctx, t.fn)
+	}
+}
+
+// Submit queues the task, waiting for the free space when the queue is full.
+// It returns the error of ctx when ctx is done before the task is queued, and ErrPoolClosed when
+// the pool is shut down while waiting.
+//
+// Same as Call, the task gets ctx without its cancellation, as it usually runs after the caller returns,
+// and runs in a new span linked to the span of ctx.
+func (p *Pool) Submit(ctx context.Context, fn func(ctx context.Context)) error {
+	select {
+	case p.slots <- struct{}{}:
+	case <-p.done:
+		return ErrPoolClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.enqueue(ctx, fn)
+}
+
+// TrySubmit queues the task, or returns ErrPoolFull without waiting when the queue is full.
+func (p *Pool) TrySubmit(ctx context.Context, fn func(ctx context.Context)) error {
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		select {
+		case <-p.done:
+			return ErrPoolClosed
+		default:
+			return ErrPoolFull
+		}
+	}
+	return p.enqueue(ctx, fn)
+}
+
+// enqueue sends the task holding a slot. The send never blocks, as there are not more queued tasks than slots.
+func (p *Pool) enqueue(ctx context.Context, fn func(ctx context.Context)) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		<-p.slots
+		return ErrPoolClosed
+	}
+	p.tasks <- task{ctx: context.WithoutCancel(ctx), fn: fn}
+	return nil
+}
+
+// Shutdown stops accepting the tasks and waits for the queued and running tasks to finish.
+// It returns the error of ctx when ctx is done first, and the remaining tasks keep running.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.once.Do(func() {
+		close(p.done)
+		p.mu.Lock()
+		p.closed = true
+		close(p.tasks)
+		p.mu.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,130 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_Submit(t *testing.T) {
+	ctx := context.Background()
+	pool := NewPool(PoolOptions{Workers: 2, QueueSize: 4})
+
+	var done, running, peak atomic.Int64
+	for i := 0; i < 20; i++ {
+		if err := pool.Submit(ctx, func(ctx context.Context) {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			done.Add(1)
+		}); err != nil {
+			t.Fatalf("Submit() failed: %v", err)
+		}
+	}
+	if err := pool.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() failed: %v", err)
+	}
+	if got := done.Load(); got != 20 {
+		t.Errorf("finished tasks = %d, want 20", got)
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("concurrent tasks = %d, want at most 2", got)
+	}
+	if err := pool.Submit(ctx, func(ctx context.Context) {}); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit() after Shutdown() error = %v, want %v", err, ErrPoolClosed)
+	}
+}
+
+func TestPool_Full(t *testing.T) {
+	ctx := context.Background()
+	pool := NewPool(PoolOptions{Workers: 1, QueueSize: 1})
+	release := make(chan struct{})
+	block := func(ctx context.Context) { <-release }
+
+	_ = pool.Submit(ctx, block)
+	// Wait for the worker to take the first task, so the second one stays in the queue.
+	time.Sleep(10 * time.Millisecond)
+	if err := pool.TrySubmit(ctx, block); err != nil {
+		t.Fatalf("TrySubmit() failed: %v", err)
+	}
+	if err := pool.TrySubmit(ctx, block); !errors.Is(err, ErrPoolFull) {
+		t.Errorf("TrySubmit() error = %v, want %v", err, ErrPoolFull)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Submit(timeout, block); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	timeout, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Shutdown(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() of blocked pool error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+	if err := pool.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() after release failed: %v", err)
+	}
+}
+
+func TestPool_ShutdownWithBlockedSubmit(t *testing.T) {
+	ctx := context.Background()
+	pool := NewPool(PoolOptions{Workers: 1, QueueSize: 1})
+	release := make(chan struct{})
+	defer close(release)
+	block := func(ctx context.Context) { <-release }
+
+	_ = pool.Submit(ctx, block)
+	time.Sleep(10 * time.Millisecond)
+	_ = pool.Submit(ctx, block)
+
+	submitted := make(chan error, 1)
+	go func() {
+		submitted <- pool.Submit(ctx, block)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Shutdown(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case err := <-submitted:
+		if !errors.Is(err, ErrPoolClosed) {
+			t.Errorf("blocked Submit() error = %v, want %v", err, ErrPoolClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Submit() does not return after Shutdown()")
+	}
+}
+
+func TestPool_Recover(t *testing.T) {
+	recovered := make(chan interface{}, 1)
+	SetRecoverHook(func(ctx context.Context, err interface{}) { recovered <- err })
+	t.Cleanup(func() { SetRecoverHook(defaultRecoverHook) })
+
+	ctx := context.Background()
+	pool := NewPool(PoolOptions{Workers: 1})
+	_ = pool.Submit(ctx, func(ctx context.Context) { panic("boom") })
+	var done atomic.Bool
+	_ = pool.Submit(ctx, func(ctx context.Context) { done.Store(true) })
+	_ = pool.Shutdown(ctx)
+
+	select {
+	case <-recovered:
+	default:
+		t.Error("recover hook is not called")
+	}
+	if !done.Load() {
+		t.Error("worker stops after the panic")
+	}
+}
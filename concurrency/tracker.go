@@ -0,0 +1,50 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+)
+
+// tracker counts the running routines. Unlike sync.WaitGroup, a routine can be added while waiting.
+type tracker struct {
+	mu sync.Mutex
+	n  int
+	// idle is closed when n drops to 0.
+	idle chan struct{}
+}
+
+func (t *tracker) add() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.n == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.n++
+}
+
+func (t *tracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.n--
+	if t.n == 0 {
+		close(t.idle)
+	}
+}
+
+// wait waits until no routine is running, or returns the error of ctx.
+func (t *tracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.n == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -7,6 +7,10 @@ import (
 
 const (
 	MetadataKeyCaller = "caller"
+	// MetadataKeyRetryable marks whether the failed call can be retried.
+	MetadataKeyRetryable = "retryable"
+	// MetadataKeyStack is the stack trace of the recovered panic.
+	MetadataKeyStack = "stack"
 )
 
 type Metadata map[string]interface{}
@@ -6,7 +6,6 @@ import (
 	gctx "github.com/aidapedia/gdk/context"
 	"github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v3/client"
-	"go.uber.org/ratelimit"
 )
 
 // Client is the client for HTTP requests.
@@ -14,7 +13,8 @@ type Client struct {
 	cli *client.Client
 	// rate limiter
 	// Key is the path of the request. Example: using path /user
-	ratelimiter map[string]ratelimit.Limiter
+	// The function blocks until the request is allowed.
+	ratelimiter map[string]func(ctx context.Context) error
 }
 
 // New creates a new client.
@@ -38,8 +38,10 @@ func (c *Client) Send(ctx context.Context, req *Request) (*client.Response, erro
 
 	// check rate limit
 	if c.ratelimiter != nil {
-		if limiter, ok := c.ratelimiter[req.URL()]; ok {
-			limiter.Take()
+		if wait, ok := c.ratelimiter[req.URL()]; ok {
+			if err := wait(ctx); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -1,8 +1,10 @@
 package client
 
 import (
+	"context"
 	"time"
 
+	gratelimit "github.com/aidapedia/gdk/cache/ratelimit"
 	"go.uber.org/ratelimit"
 )
 
@@ -28,9 +30,40 @@ type withRateLimit struct {
 func (o *withRateLimit) Apply(cli *Client) {
 	if o.rate > 0 {
 		if cli.ratelimiter == nil {
-			cli.ratelimiter = make(map[string]ratelimit.Limiter)
+			cli.ratelimiter = make(map[string]func(ctx context.Context) error)
+		}
+		limiter := ratelimit.New(o.rate, o.opt...)
+		cli.ratelimiter[o.url] = func(ctx context.Context) error {
+			limiter.Take()
+			return nil
+		}
+	}
+}
+
+// WithDistributedRateLimit is the option that adds rate limit shared by every instance using the same cache.
+// The request waits until it is allowed or the context is done.
+//
+// Example:
+//
+//	WithDistributedRateLimit("https://api.example.com/user", limiter, ratelimit.PerSecond(100))
+func WithDistributedRateLimit(url string, limiter *gratelimit.Limiter, limit gratelimit.Limit) Option {
+	return &withDistributedRateLimit{url: url, limiter: limiter, limit: limit}
+}
+
+type withDistributedRateLimit struct {
+	url     string
+	limiter *gratelimit.Limiter
+	limit   gratelimit.Limit
+}
+
+func (o *withDistributedRateLimit) Apply(cli *Client) {
+	if o.limiter != nil {
+		if cli.ratelimiter == nil {
+			cli.ratelimiter = make(map[string]func(ctx context.Context) error)
+		}
+		cli.ratelimiter[o.url] = func(ctx context.Context) error {
+			return o.limiter.Wait(ctx, o.url, o.limit)
 		}
-		cli.ratelimiter[o.url] = ratelimit.New(o.rate, o.opt...)
 	}
 }
 
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/aidapedia/gdk/cache/ratelimit"
+	"github.com/aidapedia/gdk/log"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+
+	"github.com/aidapedia/gdk/http/server/response"
+)
+
+// WithRateLimit is the middleware that limits the requests per key, shared by every instance using the same cache.
+// The key is the client IP when keyFn is nil. The request is passed when the cache fails.
+//
+// Example:
+//
+//	WithRateLimit(limiter, ratelimit.PerMinute(100), func(c fiber.Ctx) string { return c.Get("X-User-ID") })
+func WithRateLimit(limiter *ratelimit.Limiter, limit ratelimit.Limit, keyFn func(c fiber.Ctx) string) fiber.Handler {
+	if keyFn == nil {
+		keyFn = func(c fiber.Ctx) string { return c.IP() }
+	}
+	return func(c fiber.Ctx) error {
+		res, err := limiter.Allow(c.Context(), keyFn(c), limit)
+		if err != nil {
+			log.ErrorCtx(c.Context(), "Rate Limit Error", zap.Error(err))
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.FormatInt(limit.Rate, 10))
+		c.Set("X-RateLimit-Remaining", strconv.FormatInt(max(res.Remaining, 0), 10))
+		c.Set("X-RateLimit-Reset", strconv.FormatInt(seconds(res.ResetAfter.Seconds()), 10))
+		if !res.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(seconds(res.RetryAfter.Seconds()), 10))
+			// The response is already written, so the error is not returned to the error handler of fiber.
+			_ = response.JSONResponse(c, response.HTTPResponse{
+				BaseResponse: response.BaseResponse{
+					Code:    fiber.StatusTooManyRequests,
+					Message: "Too Many Requests",
+				},
+				Error: fiber.ErrTooManyRequests,
+			})
+			return nil
+		}
+		return c.Next()
+	}
+}
+
+// seconds rounds up the seconds, so the client does not retry too early.
+func seconds(s float64) int64 {
+	return int64(math.Ceil(s))
+}
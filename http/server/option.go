@@ -2,6 +2,7 @@ package server
 
 import (
 	"errors"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -65,3 +66,26 @@ func (o *withAppConfig) Apply(svc *Server) error {
 	svc.config = &o.config
 	return nil
 }
+
+// WithRoutineShutdown is the option that sets how Listen waits for the routines started by concurrency.Call
+// after the server shuts down. The context of the routines is canceled first when cancel is true.
+// Listen returns the error when the routines are still running after timeout.
+// Default is 10 seconds without cancel. Set timeout to negative to return without waiting.
+//
+// Example:
+//
+//	WithRoutineShutdown(30*time.Second, false)
+func WithRoutineShutdown(timeout time.Duration, cancel bool) Option {
+	return &withRoutineShutdown{timeout: timeout, cancel: cancel}
+}
+
+type withRoutineShutdown struct {
+	timeout time.Duration
+	cancel  bool
+}
+
+func (o *withRoutineShutdown) Apply(svc *Server) error {
+	svc.routineTimeout = o.timeout
+	svc.routineCancel = o.cancel
+	return nil
+}
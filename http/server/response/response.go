@@ -1,9 +1,17 @@
 package response
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v3"
 )
 
+// StatusCoder is the error that carries its own HTTP status code.
+// For example, callwrapper.RejectedError returns 429 or 503.
+type StatusCoder interface {
+	StatusCode() int
+}
+
 // Use this struct to create a response from usecase level
 type BaseResponse struct {
 	Code    int
@@ -24,6 +32,10 @@ func JSONResponse(c fiber.Ctx, rawResponse HTTPResponse) error {
 			"message": "Internal Server Error",
 		}
 		statusCode := fiber.StatusInternalServerError
+		var coder StatusCoder
+		if errors.As(rawResponse.Error, &coder) {
+			statusCode = coder.StatusCode()
+		}
 		if rawResponse.Code != 0 {
 			statusCode = rawResponse.Code
 		}
@@ -1,11 +1,15 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/aidapedia/gdk/concurrency"
 	"github.com/aidapedia/gdk/http/server/middleware"
 	"github.com/aidapedia/gdk/mask"
 	"github.com/bytedance/sonic"
@@ -17,12 +21,24 @@ type Server struct {
 	// Legacy attribute for fiber app
 	*fiber.App
 	config *fiber.Config
+	// routineTimeout is the maximum wait time for the background routines after the server shuts down.
+	routineTimeout time.Duration
+	// routineCancel cancels the background routines before waiting for them.
+	routineCancel bool
+	// shutdownDone is closed when the server shuts down and the in-flight handlers return.
+	shutdownDone chan struct{}
 }
 
+// defaultRoutineTimeout is the default maximum wait time for the background routines on shutdown.
+const defaultRoutineTimeout = 10 * time.Second
+
 // New creates a new server
 // Basically, it creates a new fiber app with the given config
 func New(serverName string, opt ...Option) (*Server, error) {
-	svc := &Server{}
+	svc := &Server{
+		routineTimeout: defaultRoutineTimeout,
+		shutdownDone:   make(chan struct{}),
+	}
 	optPostInit := []Option{}
 	for _, o := range opt {
 		err := o.Apply(svc)
@@ -43,6 +59,13 @@ func New(serverName string, opt ...Option) (*Server, error) {
 		svc.config.ServerHeader = serverName
 	}
 	svc.App = fiber.New(*svc.config)
+	// The listener is closed before the in-flight handlers return,
+	// so Listen waits for the shutdown to complete before it waits for the routines started by the handlers.
+	var once sync.Once
+	svc.App.Hooks().OnPostShutdown(func(error) error {
+		once.Do(func() { close(svc.shutdownDone) })
+		return nil
+	})
 	for _, o := range optPostInit {
 		o.Apply(svc)
 	}
@@ -77,11 +100,37 @@ func (s *Server) shutdown() {
 // Listen starts the server with the given address and config
 // It will shutdown the server gracefully when os.Interrupt, syscall.SIGTERM, or syscall.SIGQUIT signal is received
 // It will return error if the server failed to start
+// After the server shuts down and the in-flight handlers return,
+// it waits for the routines started by concurrency.Call. See WithRoutineShutdown.
 func (s *Server) Listen(address string, config ...fiber.ListenConfig) error {
 	s.shutdown()
 	// Handle path not found
 	s.App.Use(func(c fiber.Ctx) error {
 		return c.SendStatus(404)
 	})
-	return s.App.Listen(address, config...)
+	if err := s.App.Listen(address, config...); err != nil {
+		return err
+	}
+	<-s.shutdownDone
+	return s.waitRoutines()
+}
+
+// waitRoutines waits for the background routines, so the work started by the handlers is not lost.
+func (s *Server) waitRoutines() error {
+	if s.routineTimeout < 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.routineTimeout)
+	defer cancel()
+
+	var err error
+	if s.routineCancel {
+		err = concurrency.Shutdown(ctx)
+	} else {
+		err = concurrency.Wait(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("wait background routines: %w", err)
+	}
+	return nil
 }
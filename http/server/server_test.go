@@ -1,10 +1,17 @@
 package server_test
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aidapedia/gdk/concurrency"
 	"github.com/aidapedia/gdk/http/server"
 	"github.com/aidapedia/gdk/mask"
+	"github.com/gofiber/fiber/v3"
 )
 
 func TestNewWithDefaultConfig(t *testing.T) {
@@ -36,3 +43,55 @@ func TestNewWithDefaultConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_ListenWaitsRoutines(t *testing.T) {
+	srv, err := server.New("test-server", server.WithRoutineShutdown(time.Second, false))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var done atomic.Bool
+	srv.Get("/", func(c fiber.Ctx) error {
+		close(entered)
+		<-release
+		// The routine starts after the listener is closed, while the server is still shutting down.
+		concurrency.Call(context.Background(), func(ctx context.Context) {
+			time.Sleep(50 * time.Millisecond)
+			done.Store(true)
+		})
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	addr := make(chan string, 1)
+	listened := make(chan error, 1)
+	go func() {
+		listened <- srv.Listen("127.0.0.1:0", fiber.ListenConfig{
+			DisableStartupMessage: true,
+			ListenerAddrFunc:      func(a net.Addr) { addr <- a.String() },
+		})
+	}()
+	go func() {
+		resp, err := http.Get("http://" + <-addr + "/")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-entered
+	go func() { _ = srv.Shutdown() }()
+	// Let the listener close before the handler starts the routine.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	select {
+	case err := <-listened:
+		if err != nil {
+			t.Fatalf("Listen() failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen() did not return")
+	}
+	if !done.Load() {
+		t.Error("Listen() returned before the routine started by the handler finished")
+	}
+}
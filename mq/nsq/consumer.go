@@ -1,10 +1,13 @@
 package nsq
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/aidapedia/gdk/concurrency"
 	"github.com/aidapedia/gdk/mq/nsq/middleware"
 	nsq "github.com/nsqio/go-nsq"
 )
@@ -66,12 +69,36 @@ func (q *Consumer) Start() {
 	<-sig
 }
 
+// Stop stops all consumers gracefully and waits for them. It does not wait for the routines started by
+// concurrency.Call, use Shutdown to wait for them too.
 func (q *Consumer) Stop() {
+	_ = q.stop(context.Background())
+}
+
+// Shutdown stops all consumers gracefully, then waits for the routines started by concurrency.Call,
+// e.g. by the handlers. It returns the error of ctx when ctx is done first.
+// Call concurrency.Shutdown instead of waiting to cancel the routines.
+func (q *Consumer) Shutdown(ctx context.Context) error {
+	if err := q.stop(ctx); err != nil {
+		return err
+	}
+	if err := concurrency.Wait(ctx); err != nil {
+		return fmt.Errorf("wait background routines: %w", err)
+	}
+	return nil
+}
+
+func (q *Consumer) stop(ctx context.Context) error {
 	// Stop all consumers gracefully
 	for _, c := range q.consumers {
 		c.Stop()
 	}
 	for _, c := range q.consumers {
-		<-c.StopChan
+		select {
+		case <-c.StopChan:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
+	return nil
 }
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	// 2025-01-15 is Wednesday.
+	from := time.Date(2025, 1, 15, 10, 30, 20, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "* * * * *", want: time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{expr: "0 9-17 * * *", want: time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * mon-fri", want: time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", want: time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1,20 * *", want: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		// The day matches either field when both are restricted.
+		{expr: "0 0 1 * fri", want: time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 feb *", want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", want: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "@hourly", want: time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{expr: "@every 1h", want: time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 feb *", want: time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Cron(tt.expr)
+		if err != nil {
+			t.Fatalf("Cron(%q) failed: %v", tt.expr, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Cron(%q).Next() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every -1s",
+	} {
+		if _, err := Cron(expr); err == nil {
+			t.Errorf("Cron(%q) error = nil", expr)
+		}
+	}
+}
+
+func TestEvery_Next(t *testing.T) {
+	from := time.Date(2025, 1, 15, 10, 32, 20, 0, time.UTC)
+	if got, want := Every(5*time.Minute).Next(from), time.Date(2025, 1, 15, 10, 35, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Every().Next() = %v, want %v", got, want)
+	}
+	if got := Every(0).Next(from); !got.IsZero() {
+		t.Errorf("Every(0).Next() = %v, want zero", got)
+	}
+}
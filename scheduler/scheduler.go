@@ -0,0 +1,229 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+	"github.com/aidapedia/gdk/cache/lock"
+	"github.com/aidapedia/gdk/concurrency"
+	"github.com/aidapedia/gdk/log"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrStopped is returned when the job is added after Stop.
+	ErrStopped = errors.New("scheduler: stopped")
+)
+
+// Job is the function run by the scheduler.
+type Job struct {
+	// Name identifies the job in the log and the lock. It must be unique in the scheduler.
+	Name     string
+	Schedule Schedule
+	Func     func(ctx context.Context) error
+	// Jitter is the maximum random delay added to every run, so the jobs of the same time do not run at once.
+	// Default is 0.
+	Jitter time.Duration
+	// AllowOverlap runs the job even when its previous run on this instance is still running.
+	// Default is false, which skips the run.
+	AllowOverlap bool
+	// Singleton runs the job on only one of the instances sharing Options.Cache for every scheduled time.
+	// Default is false.
+	Singleton bool
+}
+
+type Options struct {
+	// Cache holds the lock of the Singleton job. It must implement lock.Backend.
+	Cache engine.Interface
+	// LockTTL is how long the lock of a scheduled time is kept after it is obtained. It must be longer than
+	// the clock difference between the instances, so the late instance finds it and skips the same run.
+	// Default is 1 minute.
+	LockTTL time.Duration
+	// Location is the time zone of the cron schedule.
+	// Default is time.Local.
+	Location *time.Location
+}
+
+func (o *Options) setDefault() {
+	if o.LockTTL <= 0 {
+		o.LockTTL = time.Minute
+	}
+	if o.Location == nil {
+		o.Location = time.Local
+	}
+}
+
+// Scheduler runs the jobs on their schedules. The job runs with concurrency.Call, so its panic is passed
+// to the recover hook of the concurrency package and it is traced the same way.
+type Scheduler struct {
+	opt    Options
+	locker *lock.Locker
+
+	// ctx is canceled when Stop gives up waiting, to cancel the running jobs.
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	started bool
+	stopped bool
+	// loops is the number of the routines waiting for the next run, and runs is the number of the running jobs.
+	loops sync.WaitGroup
+	runs  sync.WaitGroup
+}
+
+// New creates a new Scheduler. Call Start to run the jobs.
+func New(opt Options) *Scheduler {
+	opt.setDefault()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		opt:    opt,
+		ctx:    ctx,
+		cancel: cancel,
+		stop:   make(chan struct{}),
+		jobs:   make(map[string]*Job),
+	}
+	if opt.Cache != nil {
+		// The locker is nil when the engine does not support lock, so Add rejects the Singleton job.
+		s.locker, _ = lock.New(opt.Cache, lock.Options{
+			TTL:       opt.LockTTL,
+			KeyPrefix: "scheduler:",
+		})
+	}
+	return s
+}
+
+// Add adds the job. The job added after Start runs right away on its schedule.
+func (s *Scheduler) Add(job Job) error {
+	switch {
+	case job.Name == "":
+		return errors.New("scheduler: job name is empty")
+	case job.Func == nil:
+		return fmt.Errorf("scheduler: job %s has no function", job.Name)
+	case job.Schedule == nil || job.Schedule.Next(time.Now().In(s.opt.Location)).IsZero():
+		return fmt.Errorf("scheduler: job %s has no next run", job.Name)
+	case job.Singleton && s.locker == nil:
+		return fmt.Errorf("scheduler: singleton job %s needs Options.Cache implementing lock.Backend", job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrStopped
+	}
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("scheduler: job %s already exists", job.Name)
+	}
+	s.jobs[job.Name] = &job
+	if s.started {
+		s.loops.Add(1)
+		go s.loop(&job)
+	}
+	return nil
+}
+
+// Start runs every added job on its schedule. It returns right away.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+	for _, job := range s.jobs {
+		s.loops.Add(1)
+		go s.loop(job)
+	}
+}
+
+// Stop stops scheduling the jobs and waits for the running ones to return.
+// When ctx is done first, it cancels the context of the running jobs and returns the error of ctx.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// loop waits for the next scheduled time of the job and runs it until the scheduler is stopped.
+func (s *Scheduler) loop(job *Job) {
+	defer s.loops.Done()
+	var running atomic.Bool
+	for {
+		scheduled := job.Schedule.Next(time.Now().In(s.opt.Location))
+		if scheduled.IsZero() {
+			return
+		}
+		wait := time.Until(scheduled)
+		if job.Jitter > 0 {
+			wait += rand.N(job.Jitter)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !job.AllowOverlap && !running.CompareAndSwap(false, true) {
+			log.WarnCtx(s.ctx, "Scheduler Job Overlap", zap.String("job", job.Name))
+			continue
+		}
+		s.runs.Add(1)
+		concurrency.Call(s.ctx, func(ctx context.Context) {
+			defer s.runs.Done()
+			if !job.AllowOverlap {
+				defer running.Store(false)
+			}
+			s.run(ctx, job, scheduled)
+		})
+	}
+}
+
+// run runs the job once. The Singleton job runs only when this instance obtains the lock of the scheduled time.
+func (s *Scheduler) run(ctx context.Context, job *Job, scheduled time.Time) {
+	// The context of the routine is detached from s.ctx by concurrency.Call, so Stop cancels it here.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(s.ctx, cancel)()
+
+	if job.Singleton {
+		// The lock is not released after the run, so the instance running late for the same time skips it.
+		key := job.Name + ":" + strconv.FormatInt(scheduled.Unix(), 10)
+		if _, err := s.locker.Obtain(context.WithoutCancel(ctx), key); err != nil {
+			if !errors.Is(err, lock.ErrNotObtained) {
+				log.ErrorCtx(ctx, "Scheduler Lock Error", zap.String("job", job.Name), zap.Error(err))
+			}
+			return
+		}
+	}
+	if err := job.Func(ctx); err != nil {
+		log.ErrorCtx(ctx, "Scheduler Job Error", zap.String("job", job.Name), zap.Error(err))
+	}
+}
@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aidapedia/gdk/cache/engine"
+	"github.com/aidapedia/gdk/concurrency"
+	"github.com/aidapedia/gdk/log"
+	"go.uber.org/zap"
+)
+
+func init() {
+	log.Log = &log.Logger{Logger: zap.NewNop()}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := New(Options{})
+	var runs atomic.Int64
+	err := s.Add(Job{
+		Name:     "count",
+		Schedule: Every(10 * time.Millisecond),
+		Func: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := s.Add(Job{Name: "count", Schedule: Every(time.Second), Func: func(ctx context.Context) error { return nil }}); err == nil {
+		t.Error("Add() of duplicate job error = nil")
+	}
+
+	s.Start()
+	time.Sleep(55 * time.Millisecond)
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() failed: %v", err)
+	}
+	n := runs.Load()
+	if n < 3 {
+		t.Errorf("runs = %d, want at least 3", n)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := runs.Load(); got != n {
+		t.Errorf("runs after Stop() = %d, want %d", got, n)
+	}
+	if err := s.Add(Job{Name: "late", Schedule: Every(time.Second), Func: func(ctx context.Context) error { return nil }}); !errors.Is(err, ErrStopped) {
+		t.Errorf("Add() after Stop() error = %v, want %v", err, ErrStopped)
+	}
+}
+
+func TestScheduler_Overlap(t *testing.T) {
+	s := New(Options{})
+	var running, peak, runs atomic.Int64
+	_ = s.Add(Job{
+		Name:     "slow",
+		Schedule: Every(5 * time.Millisecond),
+		Func: func(ctx context.Context) error {
+			if n := running.Add(1); n > peak.Load() {
+				peak.Store(n)
+			}
+			runs.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		},
+	})
+	s.Start()
+	time.Sleep(60 * time.Millisecond)
+	_ = s.Stop(context.Background())
+
+	if got := peak.Load(); got != 1 {
+		t.Errorf("concurrent runs = %d, want 1", got)
+	}
+	if got := runs.Load(); got < 2 {
+		t.Errorf("runs = %d, want at least 2", got)
+	}
+}
+
+func TestScheduler_Singleton(t *testing.T) {
+	cli, err := engine.NewMemoryClient(engine.MemoryClientOpt{})
+	if err != nil {
+		t.Fatalf("NewMemoryClient() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+
+	var runs atomic.Int64
+	job := Job{
+		Name:      "sync",
+		Schedule:  Every(20 * time.Millisecond),
+		Singleton: true,
+		Func: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	}
+	if err := New(Options{}).Add(job); err == nil {
+		t.Error("Add() of singleton job without cache error = nil")
+	}
+
+	// Both instances use the same clock, so they race for every scheduled time.
+	instances := []*Scheduler{New(Options{Cache: cli}), New(Options{Cache: cli})}
+	for _, s := range instances {
+		if err := s.Add(job); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+	start := time.Now()
+	for _, s := range instances {
+		s.Start()
+	}
+	time.Sleep(110 * time.Millisecond)
+	for _, s := range instances {
+		_ = s.Stop(context.Background())
+	}
+	ticks := int64(time.Since(start)/(20*time.Millisecond)) + 1
+	if got := runs.Load(); got == 0 || got > ticks {
+		t.Errorf("runs = %d, want between 1 and %d", got, ticks)
+	}
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	recovered := make(chan interface{}, 1)
+	concurrency.SetRecoverHook(func(ctx context.Context, err interface{}) {
+		select {
+		case recovered <- err:
+		default:
+		}
+	})
+
+	s := New(Options{})
+	canceled := make(chan struct{})
+	_ = s.Add(Job{
+		Name:     "block",
+		Schedule: Every(10 * time.Millisecond),
+		Func: func(ctx context.Context) error {
+			<-ctx.Done()
+			close(canceled)
+			return ctx.Err()
+		},
+	})
+	_ = s.Add(Job{
+		Name:     "panic",
+		Schedule: Every(10 * time.Millisecond),
+		Func:     func(ctx context.Context) error { panic("boom") },
+	})
+	s.Start()
+	time.Sleep(25 * time.Millisecond)
+
+	timeout, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("running job is not canceled after Stop()")
+	}
+	select {
+	case <-recovered:
+	default:
+		t.Error("panic is not passed to the recover hook")
+	}
+}
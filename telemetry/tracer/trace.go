@@ -61,6 +61,18 @@ func StartSpanFromContext(ctx context.Context, operation string) (Span, context.
 	}, ctx
 }
 
+// StartLinkedSpanFromContext create and start a new root span linked to the span of the context.
+// It is used by the asynchronous work, so its span can be found from the caller without extending the trace of the caller.
+func StartLinkedSpanFromContext(ctx context.Context, operation string) (Span, context.Context) {
+	ctx, sp := otel.Tracer("").Start(ctx, operation,
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+	)
+	return Span{
+		span: sp,
+	}, ctx
+}
+
 // Finish finish the span
 func (s *Span) Finish(errors error) {
 	var message string